@@ -8,7 +8,7 @@ import (
 
 
 func init() {
-	data := "PK\x03\x04\x14\x00\x08\x00\x08\x00\x15taQ\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\n\x00	\x00empty.tomlUT\x05\x00\x01\x0b\xc7\x9e_,\xcc\xc1J\xc3@\x14\x85\xe1\xfd<\xc5\x81YD\x85\x84i\xb1.\n\xe2\xc2Wp\x97FH\xe3\xa93\xf4&Wfn:\xe0\xd3K\xa4\xbb\xc3\xf9\xe0w\xde\xe3}-\xa6s\xfa%,\xa6\x82K\x12\xa2&\x8b\xc8\xab\xb0@\xd2u\x13B\x17\xe2L\xd1\xda\xe1#\xb2\xf0\xee5\x89l\x19\x1b\xaf\xc4O\xe6\xc4/.\x13\xa17fX\xd4B\x9c\xd7$\xd6\xa6\x05\xa6\x10\xfd\xae\x1co\xcc\x9ds\x1e}?\x8f6\xc5ap\x1e\xff\x0b\xafh>O\xfdC\xf7\xf4\xf6x\x1a\x1a\xe7q\xd1<\x8f\xb6\xfd\xfb\x10^\xda\xb0k\xc3\x1e\xbb\xc31<\x1f\xc3\xa1q\xee/\x00\x00\xff\xffPK\x07\x08\xbd\xf9\xd2\x1a\xa6\x00\x00\x00\xc3\x00\x00\x00PK\x03\x04\x14\x00\x08\x00\x08\x00\x15taQ\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x06\x00	\x00gen.goUT\x05\x00\x01\x0b\xc7\x9e_\xd2\xd7O\xcf\xb7JO\xcdK-J,IU(.I,\xc9\xccV\xd0-.J\xb6\xd5S\xd0M\xe3\xe2*HL\xceNLOUH,.N-)\xe6\x02\x04\x00\x00\xff\xffPK\x07\x080\xf3\x8fG5\x00\x00\x00/\x00\x00\x00PK\x03\x04\x14\x00\x08\x00\x08\x00\xef\x85TR\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x0e\x00	\x00logweaver.tomlUT\x05\x00\x01\"=1`\xdcW\xdfS\xe3\xb6\x16~\xe7\xaf8c\x1e6i\xfcC\xb2\x13\x93hJi\x16\xd8-{a\xd9\xc1\xde\xd9\xee\x06\x97\x11\xb6p4\xd8\x92+\xc9\xa1i\xd3\xfb\xb7\xdf\x91\x13J\x80\xedr;\xf7\xedz\x86 \xcb\xc7\xd6w\xce\xf9\xf4\x9d\xa3\x9d\xdd]H\xe7\\\x03\xd7`\xe6\x0c\nvC\xdb\xca@%\xcb;F\x17LA.\xc5\x0d/[E\x0d\x97\xc2\x05*\nk{\xdd\xf2\xcax\\\x80\x91\x0f\xb6>L\x8b\x02\x96\xb2U \xef\x84\xfd\xb4j+\xa6\x81\x0b\xf8w\xe0?\x98\x19YW\xdd\x87\xcc\x9c-\xe1\x8eW\x15\x18z\xcb\xa0Q,g\x05\x139\xf3wvw\xed\xfb	c07\xa6\xd1$\x08JYQQ\xfaR\x95As[\x06\x86\xd7,\xd8mnK/\x97B\x1b*\x8c\x86\x1b\xa9\xe0\xad|\xa5\x81\x17\\\xea\xa5\xc8-\xea\x1c\xac)4Ti.JkSS\xd3-\xb03\x9b\xd5\xd4\xe4\xf3,\xdb\xd9\x85A/D!\xf6P\xe8\x85(E\x88\x8c\"\x82G>\xda\x1b\xed\xed!\xf8\x90\xa4\x90\xcb\xba\x96\xc2\xd7s\x12\x8e\xe2Q\x9f\x00\x17\xdcpZ\xf1\xdf\xd9U\xc1\x9aJ.k&\xccUY\xc9kZ]1\xb1\xe8\xf5	\xccf\x80`\x1f.1d\xd9N\xb7\x18\xec\xc3\xab_.\x07\x83\xcb^\xcf\xff\xee\x00fS\xefK68\xe8\xc3\xab\x9d52\xfb<D(\xf6\x90\x05\x93\xe2\x11AC\x82F>\xea.8K\xd2W\x8f\x81\xcfB\x14\"\x0fM\xbcp\x0cxL\x10\"Q\x9cA\x92N\xd3\x8f	\x81\xc4\xb0\x060\x81\xc39\xcbo7\xfeCbh~\xeb\xfb\xfe6\xa2\x99\x85\xd3\xbf\xcc\xbe\x8e\x03\xeeq<Y\xfc\x0fg\xda4\x0eq\xeae^q/\xaf8\x13\xc6q\x9d\xc3\xd3\x13\x87\xfc\xe1\xe4uq\xff\xcc\xb2\x84\x0b\xc7u\n\xa6s\x878\xa7\xf6\xd6\xb2\xc7\xb2\xee\xec\xf3\xe1\xe9	\xd0\xa6\xa9x\xde\xf1\xccq\x1d\xaaJ\xed\x90\x99\xd3j\xa6\x04\xad\xd9\xbe\xe3:\x0d\xd5\xfaN\xaab\xdf\xc9\xfet\x9d\x8a-X\xe5\x10\x87\x8b\x1b\xe9\xb8N\xadK\x878\xc7\xbf\xb1\xbc5\\\x94\xbe\xef\xb8\x8e\xcd\xbcC\x9c\x87\x08\xa5xLBDF\x93/\xce\x9f\x0f\xde;?\x1el,\xbb 8/\xe4\xe2\xcb\xb3 \xdcC)\xd8u[\xfe\x85\xe5-3p\xcb\x96\xdaH\xc5\xa0\xe2\xdat\xc1o\x14\xbb\xe1\xbf\x11\x80'\xf8p\xe8\xa1qj\x17\x88H\x18{\xc8\xa6\xf2\x11\xc8\x07\x88/\"\xf4\xd0\x1eA\xe8\xff%W\x03\x14\xfe},^N\xd7\xe0k\xc1HX\x03a\x0c(&aL\x861\xb4\x82\xff\xda2/\x8c\xf6@/\xb5au1\xc3\x19\x81C\xc5\xa8a\x05\xe8\x8a\xe7\x0c>j\xa6 \xe9\x86\xf2\x06\x94\x94VG\xe03\xa3\xcaj\"\xaf\x9b\x8a\xb3\x02\xa8\x86\xbcU\x8a	\x03KF\xd5\xd6&\xeb\xf5\xdeQ\xb1z\xc3\xaeWgT\xad\xa6\x8dZ\x9d\xd1\xe5\xea]+V\xef\xdaj5m\xcbU\xc2\x9a\xd5ynV\xef\xe5bu\xc4\xf2\xfe\x00\x06\xb3\xcb\"\xb3\x7f\x07\xeb\xe1\x80l\xfd>\x96\x8dwT\xc0\xdf\xee\xd45\xc7\x90\x87F\x80c\x82'\x04O\xfca<\x1c\xc71\x98fQc\x02\x17\xcc(\xce\x16V$\x84,\x98\x86\x1b%k\xb8\x8d\xf4c\xa5\xe8\xcd\x907\xf12\x8b\x0cy\x13\x92\x0d\xfa\x97\xbe\x1d\xd9\x99\x17\xc4c#b_C\x86\xc6\x1e\xc6\x80Fd8$Q\xecOB\x04\x1f\xd3C\x98\x85#\x1c\xefy\xa3\x0c\xd45\xcd\x7f\xac\x97\xb4i\xae\xec\x10f\xad\xb8\x15\xf2Nd\x80\x917\x0c=\xec\xe1\xa1\xdf=\xb7\x8f=\xcd\xd4\x82\xe7\xcc\xef\x08\xed\xebE\xee\xe7U\xab\x0dS~%sZ\xf5b\x14M\xe2>\xa0\xd3\xf3\xc3izr\xfe\x9e\x00\xb0\xdfX~\xa5m\x16\xd9\xd5\xaf-SK\x17\x1a\xa9M\xa9\x98\xf6s\x82\xc3I\xfc\xad(\xc0`\xf6\xcb\xf3\x94\xfcM\x10\xbe\"\xe3\x96\xd1\xfb\xceC\x96R\x9b\xa5\x11\xc1x\xc3~\xe8\xb6\xd0\xbe\xdd@P\xebr\xdfI\x98\xb1{\x07\xda\x06\x8e^\xc3\x1d7s\xa8i3;z\xfd\xd3y\x92\x92\xce\xcb\xb9\xd4\x06\x8e^\xbf\x9f\x9e\x1d\x93u\xe8\xd6\xdc\x86\xa3\xd7\x1f\xa6IB|\xdf\xb7\xc3\xf3\x8b\x94D\x11\x8a\xe1\xe8\xf5\xc7\xe4\xf8\x82\xac\x8d2\xe7\xc1\xdd5\xb8\xffj\xb7=\x15\xc7\xc7\xbcC#2\x1a\x01\x82\xd9{iX\x06\x9f\x92\x8b\xe3\x0f\x04N%-\xac+\x8d\x92\x0b^0\x05A\xabUP\xf1\xeb\xa0\xa4\x15S\xd4\x0e\xd7\xa3+]\xd7\xbe\x96\xf7\xb5\xd7&\x88[\x11\"\x806\x97\xf7\x95\x9f\xfb\x8bx\xf8\x9bD~\x91\xc0O|\xc3\x08\x8fa\xfa\xf1\xe8$u;?\x03\x8c\x02<\xf2\xc2\xc8\xea\xd6\x10A\xef\xedY\xdawa\x96\x1c\x1fz\x11\nQ\xe6\xc2\xc9\xfb7\xe7.$\xc7\x87\x1f/N\xd2\xcf.\xd0\xa2\xe6\"X\xff\xe2h\xe8\xe3!\xf6C\xec\x87\xc3I\xa0\xf5<8<=q]HN\x7f\x9e\xe0\x11\xf2\x86\xe3\xcf.\x1c/\x980d\xad\xd1\xae-\xe5\xa6\xd5\x04t\x9b\xe7Lk\x17N\xc4\x8d$\x90\xacoo\xdajm\x08\xd4\x18V7\x06\x16\x9c\xc2\xc5\xf1\xd9yz\xec\xc2\xc9\x07\xdb\xb0)\x02\x8f\x17\xde\xda\xec.\xac\x83\x14d\x03\xef\x9e\xe7\xdd\xcc\xd4\xfbB\xbd\xdf\xb3A\xbf\xef>\x0dY\x80p\x80B\xef>d\xd0;K\xd2\xfe\x93\xc0\x1d\xe5ua\x81\xe9\x9d\xdd.\"\x00@\xe0\x8d\xe2p\x9e\x1b\xc01 le9\x9atQ\xb5\x8f\xb8\xaa\xef\xa8b\xc9\\\xdeMs\x9b\xf0Fra>Iu\xcb\x14!\xb4(~\xae\xab\x94\x96:\x95\xdb\xa6\x17L7RhF:A\xcb\x85\xc5\xb8\xc5\x00\x02\xbd\xde\x99\x14\xab\xb4e\xabO\xacX\xa5\xf3v\xf5F\xf1UB\xcd*iE\xdf\xb7}\x19\xd9\xf6\xefL\nx\xac\xb2`]~\xe2\x9dm\x99\x0b\xdb\xef\xee\xec\xc2\xf7\xd6\xbd\x1f \xf6\xces\xe3Yo\x88\xd5\xdehDF\x91?\x0ec\x9b\xda\xb5\xfd,\xc4q\x9c\x11\xa0m\xc1\x0d\xd8JJ6\xe4\x98\x80\xad\xb8\x85\x14\xec\x01\xfa\xf7\xb3u\x06:\xdd\xfda;'^68 \xa4K\x96\xff\xbc\xb1\xf4\xdeQ\xe1Y\xc8\x84l\xeb\xd1\x13\x07\x043\x16\x93o\x14\xcd\x99\xe5\xf96|\x84\x08\nI4\xf1\xf1d\x0c\xdf}\xf7=h\xa65\xc1C\x1c\xc1\x9d\xe2\x86\x91\xa7[,\x1b\x1cx\xff\xb8\xf0y\x9b7\xffWW\x14\xd3f\x9d\x89O\xacX\xd3k\x08ad\x85(\xdc\xbb\xa7\x17\x9e\x84>\x8e\xc7>F>F\x13X\xc7\x1d\x00~J\xd3\x0f\x01\xf61\xbc=N\x01\x02\xfb\xad`}\x1e\nT+\x04\x17e\xc0\x85a\xea\x86\xe6,X0\x00\xb0t\x80\xf3\x7f=\xaa\xf9\xdf\xa2\x18<\xe4\x91@\xe7#\x81\x7fN\xb8\x8avNB\xcf\x0e\xba\x83\n\x15E\x7fg\x17\xca\xbc\xa2\x16\x154F\x07\x13\xd8\\\xc4\xde!\x92\xf8\xe3\xcd\x84\x0d\xcd\xbbV@8\x04\xd4	\x97\x07\x18\x13<\x06\xe8aD\xa2\xbd\xfe\x83?/l\x99\xe7\xfex\xdf\xf2\xe7\x89\x1f\xb4i\xf8\xfd\xc6\x19b<\xc1!\x00\x1e\xe18\x9cL\xe2\x18Olj`*h\xb54<\xd7p,J.\x18\x1cJahn6J\xe8\xc7~'\x90\x9d_\x9e\xe7=\xfa\x7f\x9f\xe7\x10\xf9x\x14\xc1_\xaf\x1ekC\xaf+\xae\xe7\xac\xe8\xec\xee\xe7\xd9\xd6|W`\x9f-\xfe\xe4\x8b\xaf:\x03\xfb\xfc53\xf4\x15l\xf1`\xc3\xe7K=8\xe8\xe1\xd9\xd0\x8b\xb3n\xe6\x0f\x8c\xff\xec_\xea'\x81\xb0\xddK\xd7\xaa\x94]\x05\xc5c\xdb\x1f\xd9\xf3\x1d\"\x18\x114t\xa3ho#\x9c\xb3\\\xd6\xeb\xbe\xc7\xa7\x0d\xf7\x93\xeeM[\x04NeY2\x95\xc1z\x06\xf4\xbc]\xf7\x0b\x85\xbc\x13\xae\x15_P\xac\xe0\x8a\xe5\xb6\xbf5\x92l\x8a\xae\xed\x1b\x82\xee{\x01m\x9a\x82\x1a\x1aX\xa1\x0e\xec\xfb\xf6\xd5\x0e\xd4\xb7\x8a\xe8\xb3\x82\xf0\xed\x1az\xcbXC+\xbe`\x85=\x84\xdf\x9f\xc2\xb1\x87\xc7\x80\x87\x04Gd\xb8\xb7u\xf4\xc6Q\x84\xc3>\x81FV\xd5\xd5\x8dTW\xb7\x91\xbeR\x8c\x16K{\xda\xee\xc0\x83bF-O\xec\xbe\\\xd0j\x1f\xa3g\xe7\xee\x0e\xa9\xc5\xfcW\xdd\x7f\x11\xf2\x7f\x02\x00\x00\xff\xffPK\x07\x08<\xe3\xcb\x0f\xe2\x07\x00\x00(\x11\x00\x00PK\x01\x02\x14\x03\x14\x00\x08\x00\x08\x00\x15taQ\xbd\xf9\xd2\x1a\xa6\x00\x00\x00\xc3\x00\x00\x00\n\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xb4\x81\x00\x00\x00\x00empty.tomlUT\x05\x00\x01\x0b\xc7\x9e_PK\x01\x02\x14\x03\x14\x00\x08\x00\x08\x00\x15taQ0\xf3\x8fG5\x00\x00\x00/\x00\x00\x00\x06\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xb4\x81\xe7\x00\x00\x00gen.goUT\x05\x00\x01\x0b\xc7\x9e_PK\x01\x02\x14\x03\x14\x00\x08\x00\x08\x00\xef\x85TR<\xe3\xcb\x0f\xe2\x07\x00\x00(\x11\x00\x00\x0e\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xb4\x81Y\x01\x00\x00logweaver.tomlUT\x05\x00\x01\"=1`PK\x05\x06\x00\x00\x00\x00\x03\x00\x03\x00\xc3\x00\x00\x00\x80	\x00\x00\x00\x00"
+	data := "PK\x03\x04\x14\x00\x08\x00\x08\x00\x8f\xbeP]\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\n\x00	\x00empty.tomlUT\x05\x00\x01\xbf\xb8\xd2jl\x92\xcf\x8e\xd30\x10\xc6\xefy\x8a\x91z\x08\xac\xea\xe0\x16v\xb5D\xaa\xd0\xb2\xe2\x8cD\xcb\x85nA\xae3i\xac8\x9e\xc8\x9e\xb4\xcb>=\x9a4K\x8b\xc4\xd13\xe3\xdf7\x7f\xbel6\x83\xc7!1u\xee\x05\x81\x1b\x97\xa0v\x1e\xe1\xe4\xb8\x818xL\xe0]+\x19\x04\n\x08{\xf4t*`\xd3`\xc2)\x7fr\xde\x0b\x86M\x8b\xd0G\xb4Xa\xb0\x08t\xc4\x08\xdcPB\xd8\x0f\xce\xb3r\x01\x98\xc0\xd3\xe1\x84\xe6\x88\xb1\x805\xe2\xe5)\x08\xa5RC'Uam\x06\xcf\xcaR\xa8\xdd\x01j\x12\xce\x15e\xd4\x9d\x83	\xd5\x18O\xc8\xec\xc2!\x81\x193\x02\xb2&@c\x8eX\xc0\xb7!\\4@)\xdb\xa0m_\xc9\xa6f\x8c\x80\x95\x93\xff\x97\xe9\x8b,\x9b\xc1v\xdb\x19\xb6\xcdn\x97\xcd\x00\x9fM\xd7{\x84\x15\xe4\xdb\xa5^j\xa5?\xaa\xe5=,\xeeK\xad\xcb\xf7w;Xo\x1e6\xdf\xd7%\xac\x19{X\x94\xf0\xd8\xa0m]8\xf7\xbefc\xdb\xa2(\xf2\x91\xd4\xa3e\xac\x04u!m^I?\xa4f\xd4\x95\x82\x9fO\xdb7\xc5\xcd\xa7\xb7O;	\xd7\x14;\xc3\x12_j}\xa7\xf4B\xe9%,nK\xfd\xa1\xd4\xb7y&c?@\x1fI&\x80\xfd\x10*9\x8e\xa5\xae3\xa1R\xde\x05\x04\xea\xd9QHs\x08\xa6\xc3j\x9a\x9e\x1bt\x11<\x9d\x9b\xed\xe6\xa2#\xac!M.\xb8\xde\xde\x84_\xa5\xa1\xef)r\x1aW\xfc\xf5\x8c\x85\x83;b\x00\n\x82|\x15\x06\x11\xfe\x8f;\x8a\xc9<rC|\xb6~\xa8\xf0\xd79b\x1b\xa2\xbf\xde\xda\xff\x1e{=\x1fd\x12/\xae\xc4\xe58\xec:|\x11o\xae \xff2D\xea\xf1\xddg\x8c\xde\x05Y\x1a\xd5uBY\xda6_\xe84\x8f&y:\xdc\xe4\xf2/ao\xa2a\x8a\xb0\x02\x8e\x03\x8e\xd7\x19;\x19\xcbo\n\x8e\xc6\xe2X\xfao\x87\x92\xddGc[d\xac\xf2]\xf6g\x00PK\x07\x08D{!'\xe3\x01\x00\x00D\x03\x00\x00PK\x03\x04\x14\x00\x08\x00\x08\x00\x8f\xbeP]\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x06\x00	\x00gen.goUT\x05\x00\x01\xbf\xb8\xd2j\x00/\x00\xd0\xff//go:generate statik -src=. -f\n\npackage assets\n\x03\x00PK\x07\x080\xf3\x8fG6\x00\x00\x00/\x00\x00\x00PK\x03\x04\x14\x00\x08\x00\x08\x00\x8f\xbeP]\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x0e\x00	\x00logweaver.tomlUT\x05\x00\x01\xbf\xb8\xd2j\xd4Zos\xdb\xb6\x93~\xefO\xb1\xc3\x9c\x87v,R\x04\xf5\xc7\x16\xa6j~\x8e\xe3\xb4\xce\xc5q&b\xa6Ml\xd5\x03\x91\x90\x843I\xb0\x00(G\xad{\x9f\xfdfAR\x16\x15'\x99\xdf\xdd\xab\xf3Ll\x91\x04\x17\x8bg\x9f}v\x01e\xef\xd93\x88\x96B\x83\xd0`\x96\x1c\x12>gej \x95\x8b{\xceV\\A,\xf3\xb9X\x94\x8a\x19!\xf3\x0e\xb0<\xc1\xb1\xb3R\xa4\xc6\x139\x18\xf98\xd6\x87\xd3$\x81\xb5,\x15\xc8\xfb\x1cM\xab2\xe5\x1aD\x0e\xff\xdd\xf5+C\xdd\xcd\xe8\xc7O\xbe\x91Y\xda\x01\x06~\xfb\x16\xcc\xb8\x16	\xaf,\xa6r\xa1; \x150\x98\x8b\x94\xa3\xf5\x85X\xf1\x1c\xee\x85Y\x82\xe7U\xe6+\x07\xcd\x92\xaf\xe1^\xa4)\x18v\xc7\xa1P<\xe6	\xcfc\xee\xef={\x86oN8\x87\xa51\x85\xa6\xdd\xeeB\xa6,_\xf8R-\xba\xc5\xdd\xa2kD\xc6\xbb\xcf\x8a\xbb\x05Z\xd4\x86\xe5F\xc3\\*\xf8E\xba\x1aD\"\xa4^\xe71\xa2\x11\x03\x0e\x85\x82)-\xf2\x05\x8e\xc9\x98\xc1	\xe0\xb4\xbe\x80\x98\xe5 rm8K`\xc6\x11\x07\xc4X\x9bu\xcaA\xceA\x1bU\xa0\x8d\x0ep\x7f\xe1\x83\xbb\xff\xc9\xdb\xcf\xbc\xfd\x04\xf6\x7f\xa5\xfb\x97t\x7f\xe2\xef\xcf\xddjE\xccB\x89\xc6\xd1\xe6\x92\xad80H\x856h\xa7\x9aMc,\x8cZ\xdbiJ\x95\xd7V\x9b\x87c\xb8v\xf7\x93\xee\xfe\xac\xbb\xff\x89\xeeG\xb0\xff\x97\xdb\x01w\xff5\xecG\xee\xb4\xc1%Zr\xbb*mXV4\x9cX(Y\x168\x0f:\xaf\xf8\x82\x7f\x81\x9ce<\x01\xa3\xeb9\x0e^\xbc\xff\xc9\xe8\x9f}\xdf?\xb4!\x92f\xc9\xd5\xbd\xd0\xdc\xbe2\x17J\x1bt\xdd\x1a\xf2\xe1\n\x9f\xd6&\xec-\xdd\x01]\xc6K`\xdaZJ\xf9\x8a\xa7\xb51\xbc^Jm\xb6.\x0b\x91\xd8+\x90\n\x8d\xe2\x88L/\xea\x01Lq`\xc6\xb0x\x89\xfeI\xe0,^B*r\x8e\xc6\xe7\x82\xa7\x89n\xd6z\x8e\x8f\x90JPj^\xb1\x1f1\x06\xb3d\x06\x16\xdch`[X\xcc\x95\xcc\xec\x98LV\xa0\x0b\xe4\x05\xae\xcc\x9a\xd7>|\xb0\\\xc7\xb7\xd1\xadD\x02\xff\xb3di\xba\x86{\x9e\xa6\xd6\xb1B\xf19W\x8a'\x18\"\xa9\x12\xae:P\xe6)\xd7\xba\x8e/\xe8j\xdaB	\xa9\x84Y\x83\x07f)5\xaf8\xce`)\x16KnW\xbd\x19\xd1\xb6{\xb0\x9d\xc3BCp\xe8\xc3i\xb5,\xe4\xcd\x8cC*2a*l\n\xa6\x8c\x88\xcb\x94)\x0b\x83\xb6\xb3\xa0\xf1E*gMh\xab'H\x1eL\xb0\x043\xf4\xb9;\xc5 \xf3/qZ&\xfc\xf6q\xc4s\xdf(\x16sw\xeaW\xe0nD\xc2:\xb0d\x1a\x18\x9a\xc7\xc8o\xb3\x1a\xd1hD\xa3\x96\x1b\xeb\x8a\x85[\xb3\x8c\xdb7@\xf1\"e1\xca\x89\x01\x0f\x1dH\x84f3\xf4[\x98N\xe3;\xda\xbf\xbe\xce\x98\x89\x97\xd3i3\x19\x8c\xc1\x9d)\x16\xdfq\xc3\x13\x17\xef\xd6\xaf&0\x06\xa3J^\xbf\xb8\x85\x94\xe6\xa6\x8a\x10\x8c\xc1I\xb8\x8ey\x9e\x88|\xe1`:Y)\x82{%\x8c\xe19\xe4\xfc\x9ekS\x91\xdc\x92\xdfa\x8f\xa3\x8dD\xbb\x98\x8d \xe7s\xbb V\x1a\x99Y\xf9H\xb8\xe11\xaa*\xe6\x96M\x004\xec\x7f\xed\x8cM\x92\x8af r#!+S#<\xbc\x01\x8a\xc7R%[\x19\xc4\xe0\x0d[1\xd0\x86\xc5w`\xe3\xd1\x81\xfb\xa5\xc0\xf4R\x1c\xeexa\xe9i\xe4\x82\xdb4,s\\\xa4i\xe5}\x95\xeaBm\xf1\xdb\x87	7hT\x19\x94\x19V\xeb\x80\x05\x1a\xd5\xaf\x95\xe7\xd619\xaf\x92\xafr\xd0\xc6\xbb\x8bA\xe3\x15\x03\xd57\xcd\xa4Lc\x80-\xfb\xdc?\xfe\xc3\xb5\x90\xd7\xcbD\xeb\x9a\x17L1\xe4\xf0l\x0d\xb3\x94\xe5wu\x06n\x80\xc3\xcb:er\xe0L\xa5\x82\xab\xadT6K\x96[\x87\xed\xb8\x19\x9fK\xc51Y\xb0V\xd8\x826\xb3\xaan\xb3\x1c\xd3\xd9\x8eC\xeb\xf0z7\xf8\xa8\xc1\xc6\xa0\x9a\x97vx\x9d\xd4\xd6\xf7\xd9\x1a4_q\xc5R0K\xc5\x19\x06\xa9Ns\xccE\xcdm \x14\xb7\xef\xdc\xde\x8b<\x91\xf7\xc8\xd4A\x10d\xdaE&5\xcfp~\x14p\x12\x04\x01\xfaW\x94\xa6\xe6\x8b}0\xc3H7\x8a\xd2\xd4\x9b\x06\x8d\xa8Yv%M\x90\xc8\xdc5 r\x9b\xbb\xb5\xc2\xfd%Q\x1f\x15o!\xf01:ki\x07f\x04\xae\x0b\x9d\xde\xbc4\x06\xf7\xbcT\xb2\xe0\xdd\x97\\\xa5\"w\x9b\xe97u\x18\xacq\x0c\xe1WrS+\x12\x1aneoc\xbdJ\xe0F^\xdc\xe7\x19S\x82%\xb3\xe7\xa8A6\x8b\x9fv\xe1\xeb\x85W}\x02\xca\x0f\xc4\xb2\xcc\x0dh\x91\xc7(\xf4\x1c>\xe6\xe2\x0b\xf0B\xc6\xcb:jd\x18\xd4?>	{\x9d\xc6I\xac\xf2\x96qhZsc\x90\xf4e.l6\xb8\x1aKif\x7f\x97U\xe8\xdc\\o\xb0@b\xb0\xba\x0e[\xf8~\xc3\x8e\xa5\xf2\x01\x95IJ\xe3v\xac7\x95wBo\x9a\x06[u\xee\x97\xbcn\x1c\xd6\xda\xf0\x0c\xf0\x05\x8e\x08\xe38H2\xae\x17hU\x96\xa6(\x8dok8\x0e\xb1Qz\xe4\xb55\xe5y\xf8\xc4\xc3'V\xabxZ\xd7\xe8\x94\x19T1|\nsYb\x93W\xcdiK9\x9a\xb7\xbc\xf7\x80\xc1\x0c\xdf\xb69Q\xd9\xec\x16J\xc6]m\x98\xc1\x16\xae\xf21\x01gb\x982e\x01s\x91\x0b\xbd\xe4\x89c_\xb1\xb3\xe2\x84w\\\xe5<umF;oE^~\x81\x15WZ\xc8\xbc\x1a\x08^S.\x11i;y\xd3>aEH\x84\xe2\xb1\x91j\x8d\xf6\x98\x8a\x97b\xb5i\xee\xa2\x9a?\xc0f3\xc5W\xc2v\xae\xd5\xeb\x1bN<\xea\xe5\xfbI\x846\xce\xce'Q\xc7V\xd3T\xca;\x9e@Y\xe0\x1blS\xc4\xd0Q\x83E\xc3\x87\x89\xcc\xaa|a\xd9L,JY\"0\x17\x93hWD.\xf2D\xb0*\x13\xce&\x11|\x9c\xc0\x19\xcf\x0d\n\x82\x07ZZ\x18\xe4|^\x0b\x01\xa6\x08\xcb[>7\xe4\x8b\x97,_\xf0\xa4\x95%\xdb\xe3\xb4M\x14t`\x0c\xeeQ@h\x104Y\xb0\x95\xc0U\xd3\x98\x03\xff\xc2\xb2\"\xe5u8\x9a4Eh@\x18\xd0KY\xa6I-\x81H\xf9\x8ae\x1f^\x9fA\xaf\xd7\x1b\xd54\xee\xe0\x84\xa8\x06\xc0\xbf\x14<F1\x1e\x83\x1b\x06a\xe0\x05#/<\x89\xc8	\x0d\x02\xda\x1b~v\xc1{,\xe6k\xce\x14`n\x81\x98\xef\xd4\x9cDr\x8d\xca\x84^\xa2q\x89\xce!$\xba\x19\xdbb?\xc6\x86\x8c\x8e\x03\xf4g'\x95\xb0\x13\xcb\xb760\x9e\x17/y|Wo\x10\xd0\xb6\x91`oU\x8aXU)D\xc9&9\xf6\x17\xba\x01\xa9\xc1\x87\x19\xc8\xe5\xa6\x94\xd8\xc1Ov\x88h\xddj\x82\xb4d\xdc{lE\x9a>D/y\x9az\xb6.\xbb{\xf5,\xe8\xfa\xd1A\x18\x84\xc4\x0bB/\x0c\xa2 \xa0\x83\x1e%\x03?8\x1e\x1c\x1f\x07\x96\xa3\xb1\xcc2\x99\xfbzI\xc3\xc1ppHA\xe4\xc2\x08\x96\x8a\xbf\xf8m\xc2\x8bT\xae3\x9e\x9b[\xec\xddXz\xcb\xf3\xd5\xc1!\x85\xebk\x08`\x0c7\x04\xa6Swo'VO\xcf\xe6\x05\x18:w\xcf\xd6d\xf4\xec\x8f\x9b\xa3\xa3\x9b\x83\x03\xff\xf9\x0b\xb8>\xf5>O\x8f^\x1c\x82\xbbW\xefp\xac\xa5`\xe8\x05h,\"\x03\x1a\xf4i0\xf01\xc6A\x00\x97\x93\xc8\xdd\x06\xe1Y\xadV\x0d\x18\xf6\xaa\x05\xc35	{\xfd\x81?<>\x19\x05d\n\xdc,\x03\x8aL\xbd\x83\xb2h/\x00\xa3o\xa7%Q\xd0\xa3\xe1\x80n^\xfb\xdcr\xfe\x1a\x9e\x1f\\\x07\xdehzt\xe3W\x7f\x0fo\xa6\xe0\xeeY\xf1\x1e\xa3v\xef\xb5\xf9\xf3T\xd4\xb6\xba\xc7mg\x1f	\x0f\x0d\xe1\xa70\x89N\xa3\x8f\x13\n\x13\xc3\x0b \x14\xce\x90j\xf5\x0e\x11&\xd8\x96\xf9\xbe\xef\xee\xfd0qZ\x8b@\xf8\x0fo\xa6O\xe3\x0e\x0d\xee;`\xff\xed\xd8\xad\x94C\x9d\x84\xcf\xca\x85\xd3q2\xbdp\xa8\xf3\x0b7p\xc7\xd7\xda`\xe3c7\x91\x98E(\xb7\xe2\x0b\x05p:\x0e\xaa\x81C\x1d\xeb\x17	\xbd\xe0$B\xfb=\x1a\x0e+z8\xffX\xf3\xa7E\xe1P'[\xc7\xa9\xf0\xe2T\xf0\xdc8\x1d\xe7\xec\xed\x85C\xffv\xe2,i\x9ea2\x8a\xdc\xe9\xd8F\xda\xa1\xce[\xb9@-\xaf\x14\xf0\xf2\xd3\xd9\xdb\x0b`E\x91\x8a\xd8*\xb5\xd3q\x98Zh\x87^;\xa5\xe6\nC0v:N\xc1\xb4\xbe\x97*\x19;\xd3\x7f:\x9b\x85\x89|.7\xeb:\xff\xc2\xe3\x12+\xb3\xef\xef\xaca\x83m\x18\xd0\xc1\x08+\xfbQ\x10V\xebh\xb8\xf8_Z\xe6\xb60\xb6\xf8\xf8\xffv\x8d\x9f\x9d\x7f\xbe\xc7\xb1j\xcc\x16\xc7\x9c\x7f\xbd\xa8\x83n\xa9\xe6\xfc \xc3?\x07\xc7V%Z|\xfb\x84\xf2n\xbb\x97z\x9b\xdbn_\xb0\x93\x83{\xa6\xab\x0e\xbfn\xa17\xf8\xeb\xb5\xb6}\xdd\x16\xf8\x13^@8\x84`H\xc3!\xed\x0f\xb1\xdf\xfa\xb3\xe4^\xd8;n\xda\x8ck2\xa5p\xa6\xb8\xdd\x0e\xe8T\xc4\x1c>j\xae`b?\xca9()\xcdN\xb2\xa14Y \x86Qc\xb9\xa5\x18\x07\x07oX\xfe\xf0\x9a\xcf\x1e.\x99z8-\xd4\xc3%[?\xbc)\xf3\x877e\xfapZ.\x1e&\xbcx\xb8\x8a\xcd\xc3;\xb9zx\xc5\xe3\xc3#8\xba\xbeI\xa6\xf8\xefE\xf5\xf1\x88n\xfdn\xcb\xe5\x1b\x96\xc372\xb6\x81\xa2\x90\xda,\x14\xd7-&\xda\\\x0cN<B \x18\xd0~\x9f\xf6\x86\xfe(\x0c\xb0O\x87\xebp@\x86\xc7\xde`\nj\xc6\xe2\x7fekV\x14\xb7\xf8\x11\xae\xcb\xfc.\x97\xf7\xf9\x14H\xe0\xf5C\x8fx\xa4\xef\xdb\xe7\xf8\xd8\xd3\\\xadD\xcc}Ka_\xafb?NKm\xb8\xf2S\x19\xb3\xf4`\x18\xf4F\xc3C\x08\xde^\x9d\x9dF\x17W\xef(\x00\xff\xc2\xe3[-0F\xb7\x7f\x96\\\xad;\xd0\xf8\xeb\xc7\x94\x84\xa3a\x1b\xeeG\xbf\xa3-\xbf\xdb\x88\xa3.{S\x841\xf0F\xd4\xb7\x9fl\xa9\xf9V\xa5\xd9\xe0\xe7?Uf\x1a\x1c\x85\x96^&b%\x9f@\x92\x04^0\x002\xa4dD\xc9\xc8\xef\x0f\xfb'\xc3!\x98b\x95\x11\n\x1f\xb8Q\x82\xafP\xb4s\x99p]\x11\xf9\xae\xa7\x9fVnk+jl}oi\xd3\xa3\xc3\xa6\n\xc1\xd1\x0f\x96\xf6d!J\xe5b\x9e\x99\xd6r0m\xc7\xce\x8e#\x03JH-q`\xb5r\x8c*\x02\x99^\x8c\x9dI\xb3})\xe0\xd5\xcb\xaa9\xcbXq\xfd\xea\xe5\xafW\x93\x88\xda\xc0\xe3\x91\x1b\xbcz\xf9\xee\xf4\xf2\x9cZ\xb6\xdc\xd6\xdd\xd7\xab\x97\xefO'\x13\xea\xfb>~\xbc\xfa\x10\xd1^/\x18\xc2\xab\x97\x1f'\xe7\x1fh5h\xea<\xc1\x80\xa7\\\xdb\x02\xaaZ\xc4\xff^z\xc2\x80\x04U<\x83\x01\x1d\x0c\xe0\xfa\x9d4|\n\xbfM>\x9c\xbf\xc7x\xb2\x04r\x91\xc2\xef\x17\xaf\xaaXb\xedc\x0b\x0e<_\xe0\x1e\xba\x03\xfaN\x14\x05\xa2RH-\xb0\x0c\xd9\xfejC\xa5z\xef\xf9\x1d\x1eU\xf3\x06;3\xbf\x95\x0c\xcf\x8d\xa0Pr%\xf0\xa4\xa5[j\xd5M\xc5\xac\xbb`)W\x0c?V\x9fnu\x96\xf9Z6m\xdd\xc6\x0f\n\xf5~4\xf0\x9e\xf8\xd5\xfcP\x8f|\x0ft\xeb\xdc\x0f\x88\xf9HH<k\xb8~\x92\x92\x1dp\x83a@\xb6Y:}\x8a\xa6:\xfd\xe2\xb12\x11m\xa6\x92\x80\x9c\xc0\xe9\xc7W\x17Q\x07\x90\xaf]\x12t\xc9\xc0\x0b{X\x8e\xfa\x01\x1c\xfcr\x19\x1dv\xe0zr~\xe6\xf5\x820\x98v\xe0\xe2\xdd\xeb\xab\x0eL\xce\xcf>~\xb8\x88>u\x80%\x99\xc8\xbb\xd5o\xd2\xeb\xfb\xa4O\xfc\x90\xf8a\x7f\xd4\xd5z\xd9={{\xd1\xe9\xc0\xe4\xed\xef#2\x08\xbc\xfe\xc9\xa7\x0e\x9c\xafxnh\xd5\x82t\xb0\xff2\xa5\xa6\xb8\xfb\x8b\xb9\xd6\x1d\xb8\xc8\xe7\x92\xc2\xa4\xba\x9c\x97i5\x10\xcf\x90yV\x18X	\x06\x1f\xce/\xaf\xa2\xf3\x0e\\\xbc\xc7\xef7\x14\x85\xf6\xc4\xdfP\x042\x88\x9a\x85m\x03\xdf\x81J\xed\xba\xd3#\xafF\x1en\xec\xadS\xef3\xf3\xfe\x9a\x1e\xdd\x1c\x1evv\xa5\xa1\x1b\x90n\x10zM\n\xc0\xc1\xe5$:\xdc\xe9\xf6^\xc5Y\x82\xde\xebM\x14\x928k7\xac\x88&\x00Px\xad\x04\\\xc5\x06\xc8\x10\x02\x82\xd5\xb57\xb2\x11\xc1GBe\xf7L\xf1\xc9R\xde\x9f\xda\xe3\xc9B\x8a\xdc\xfc&\xd5\x1dW\x94\xb2$\xf9=K#\xb6\xd0\x91\xdc\x1e\xfa\x81\xebB\xe6\x9aS\xab\x98q\x8e\x82\xfd\x0dJ\x92a\xd4L\xba\x8d\x0c\x85\x83\x83K\x99?D%\x7f\xf8\x8d'\x0f\xd1\xb2|x\xad\xc4\xc3\x84\x99\x87I\x99\x1f\xfa\xb8\xf1\xa0\xdb\xc8\\\xca\x1c\xda\xe5\x14\x10\xac\x1d\\6\xe7\xd6\x1b\\\xec\x9d\x160?!2?\xc3\xd0\xbb\x8a\x8d\x87~R\xac\x0b\xbd\x01\x1d\xf4\xfc\x93p\x88\x8c\xaa\xec\\\x87d8\x9cR\xb0\xe4\xc6/\x0e\x14\xad99\x02\xec\xf1\x12\x99\xf3\xa7W\x1d\x0c\xa3m\x93\x9f\xdd\xbd\xc7\x83\xf3\xed\xa3\xf5G@~\xc2\x1a\xc8\xbc\xbf\xec\xe6\xe5g\xd8\"\x897=zAiS,w\xf7c\xde\x1b\x96{\x08\x04\xa5\x0da\xb0L\xee\xc0\x92s\x83\xd3VG\xf6\x1bh\xea\xbbOlP\xc968A@\x83\x90\xf6F>\x19\x9d\xc0\xf3\xe7?\x81\xe6ZS\xd2'=\xdb\xd7q\xfa\xed\xc8o\xbd\xd9\xc2\xa0\xe5O\x0b\x87?\xea\xfd\xdb\x0b\xef\xdf\xee\xcc\xbc\xfa\xcd\xff+X\x8ak\xb3\xcd\xa0\xfa\xba\xc5\xa1\xdfxR\xe5T\x1f\xc2\x1e*nx\xdc\xe4\x14\x19\x85>\x19\x9e\xf8$\xf0I0\x82\x8a1\x00\xf0k\x14\xbd\xef\x12\x9f\xc0/\xe7\x11@\x17\xadv\xeb\xafIU\x99\xe7\"_tEn\xb8\x9a\xb3\x98wW\x1c\x00\x90\xe0p\xf5\x9f\xdf\x80\xb7\x1f53o'\xd6\x1f\xdf\xcf+x\xa4\x19\x05\xcb&\n\xff~\x96aO\x9f\xca\x05\x1c\xe0\x07{X\xc1\xf2\xe4p\xc3\xab\x94}\x0d\xd8\"N\x19.	\n\xa3\xbb#\xa8\x7f(^\x05t\xe2\x9f\xd47\x10\xd77e\x0ea\x1f\x02[&< \x84\x92\x13\x80\x03\x12\xd0\xde\xf1a\x1b\x0c,\x87^0\xf4\xc2>r\xad\x1f\xd0\xa0\xa5\xbf?\x10\x99\xaf\xc1\xf0\xbe\x07\x86\xbb\xd7|I\xe4>~I\xb4\x83\x0c+\n\xd1\xd6\x1f{\xa7\x05\x85\xeb\xf6	\x19\x91\x10\x80\x0c\xc80\x1c\x8d\x86C2B\xa6\xc0i\xce\xd2\xb5\x11\xb1\x86s\xdb\xaa\xc0\x99\xcc\x0d\x8bM]\xc9\xfc\xa1o\x0b\x1c\x02\x05\x9e\xe7\xb5\xfe6\xb4\x0b\x03\x9f\x0cz\xb0y\xf5\\\xe3A\xa6=\x95\xc5a\x9b\xfb|\xeb\xbem\x0b\xbf\x9a|\xc7\xa2k\x07\xe0\xf3\x97\xdc0\x17\\w\x97\x98\xe4\xc4\x0b\x88G\xec\xb9FHh@\xfcQ\xd0\xee\x90\xeb\x0c\xbd\xd1G/\x0e\xc8u\xdf\x1bN\xed\x9d\xbf	\xf9\xe7\xf0F?\x9e\xd3d\xba\x85kC,+\xc4-0\x1d\xb3.\xf8\x18\xfb\xd1\xdb\xb7W\xbf\\\xbc\xb3M\xaf\x1dv\xd0>\xdc\xa7\xfd\xc1\xf0\x90B!\x921\x9e:A)\x92q\x00\xac\xfa\xa3\xb9\x1e\x13\xfb\xaa+\x8bq\xdd\x14\xc4\xb1\x19\xdf8\xb8\xa7\xbcqp#4\xbeql?\xa7g\"\xc7\xfe#\xb9qP*\xc6uw\xe1:;h\x84v\xe3Iz\x11	\xb1\xe8\xf6\xad\x1b\xdbhl\\\xbd9\xd8=\xb4\xa2\xf5\xf5a\xeb\xe8\xaa\xc54\xdc\xcd\xd9\xad\xdb\xa3PU\xb7\xbc\xdd\xd4\xab\x02s\xe2\x85'@\x02J\x02\x1a\xf4;\xbd\xde\xb1m\xb7\x00\xaec\x99U\x9bD\x9f\x15\xc2\x9fX\x1b\xd8(\xbd\x95\x8b\x05WS\xa8\xee\x80^\x96\xd5N\"\x91\xf7y\x07{\x0fP\xbc:\x99\xb7\xdf0\xd3\xba\xdb\xc5\x1dE\xd7\xda\xeb\xb2\xa2H\x98a\xf8\x7f?t\x17\xdf\xc7W\xfd\xda\xbd\xedV\xa1\xf1/j\xfc\xfb\xde\x8eqz\xf4u\xcb\xb4\xdb\xba\xb6\xa1\xba\xe3\xbc`\xa9X\xf1d\x03\xd5\xe3\xadm6\xb9G\xcd\xc9,\xd2\x18H\x9f\x92\x1e\xed\x1fo\x1d\xc7\x92^\x8f\x84\xc8#\x99\xa6\xb7s\xa9n\xefz\xfa\x16\xbf\xef[\xe3	\xac]<(n\xd4\xfa\x02\xa5|\xc5\xd21	v\xd3\xa4\xb1\x1f5\xf6[\xab\xad\x0e`1\xfe\x14\xd7\xdc,\x19~\xb8\xe4\xff\x19\x00PK\x07\x082\xcc\x8d\xbb\x87\x0f\x00\x00\xde#\x00\x00PK\x01\x02\x14\x03\x14\x00\x08\x00\x08\x00\x8f\xbeP]D{!'\xe3\x01\x00\x00D\x03\x00\x00\n\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xa4\x81\x00\x00\x00\x00empty.tomlUT\x05\x00\x01\xbf\xb8\xd2jPK\x01\x02\x14\x03\x14\x00\x08\x00\x08\x00\x8f\xbeP]0\xf3\x8fG6\x00\x00\x00/\x00\x00\x00\x06\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xa4\x81$\x02\x00\x00gen.goUT\x05\x00\x01\xbf\xb8\xd2jPK\x01\x02\x14\x03\x14\x00\x08\x00\x08\x00\x8f\xbeP]2\xcc\x8d\xbb\x87\x0f\x00\x00\xde#\x00\x00\x0e\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xa4\x81\x97\x02\x00\x00logweaver.tomlUT\x05\x00\x01\xbf\xb8\xd2jPK\x05\x06\x00\x00\x00\x00\x03\x00\x03\x00\xc3\x00\x00\x00c\x12\x00\x00\x00\x00"
 		fs.Register(data)
 	}
 	
\ No newline at end of file
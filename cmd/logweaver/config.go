@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/gcla/logweaver/weaver"
+)
+
+// The name of a user's config in their home directory, and of a config for the
+// logs in a directory and those below it
+const localConfigName = ".logweaver.toml"
+
+// builtinOrigin is the origin of the rules built in to logweaver.
+const builtinOrigin = "built-in"
+
+// userConfigDir returns $XDG_CONFIG_HOME/logweaver, or ~/.config/logweaver if
+// XDG_CONFIG_HOME isn't set. It returns "" if neither can be found.
+func userConfigDir(home string) string {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "logweaver")
+	}
+	if home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".config", "logweaver")
+}
+
+// userConfigPaths returns the user's own configs that exist, in order of
+// precedence - logweaver.toml in the user config directory, then the older
+// ~/.logweaver.toml.
+func userConfigPaths(home string) []string {
+	var candidates []string
+	if dir := userConfigDir(home); dir != "" {
+		candidates = append(candidates, filepath.Join(dir, "logweaver.toml"))
+	}
+	if home != "" {
+		candidates = append(candidates, filepath.Join(home, localConfigName))
+	}
+	return existing(candidates)
+}
+
+// dropInConfigPaths returns the configs in the rules.d directory within the
+// user config directory, in order of precedence - which is by name.
+func dropInConfigPaths(home string) []string {
+	dir := userConfigDir(home)
+	if dir == "" {
+		return nil
+	}
+	paths, _ := filepath.Glob(filepath.Join(dir, "rules.d", "*.toml"))
+	sort.Strings(paths)
+	return existing(paths)
+}
+
+// localConfigPaths returns each .logweaver.toml found by walking up from the
+// directory of each of logs to the root - nearest first, for each log in turn.
+func localConfigPaths(logs []string) []string {
+	var candidates []string
+	for _, log := range logs {
+		if log == weaver.StdinName {
+			continue
+		}
+		dir, err := filepath.Abs(log)
+		if err != nil {
+			continue
+		}
+		if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+			dir = filepath.Dir(dir)
+		}
+		for {
+			candidates = append(candidates, filepath.Join(dir, localConfigName))
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+	}
+	return existing(candidates)
+}
+
+// existing returns the regular files among paths, without duplicates.
+func existing(paths []string) []string {
+	seen := make(map[string]bool)
+	res := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if fi, err := os.Stat(path); err != nil || !fi.Mode().IsRegular() || seen[path] {
+			continue
+		}
+		seen[path] = true
+		res = append(res, path)
+	}
+	return res
+}
+
+// configPaths returns the configs to load, highest precedence first:
+//
+//   - those given with --config, in order
+//   - .logweaver.toml in the directory of each log, or any directory above it
+//   - the user's config, $XDG_CONFIG_HOME/logweaver/logweaver.toml or ~/.logweaver.toml
+//   - drop-in configs in $XDG_CONFIG_HOME/logweaver/rules.d/, by name
+//
+// The built-in config comes after all of these. A config is only loaded once,
+// at its highest precedence.
+func configPaths(explicit []string, logs []string, home string) ([]string, error) {
+	for _, path := range explicit {
+		if _, err := os.Stat(path); err != nil {
+			return nil, err
+		}
+	}
+	user := userConfigPaths(home)
+	seen := make(map[string]bool)
+	for _, path := range user {
+		// ~/.logweaver.toml is the user's config, even when walking up from a log in
+		// the home directory finds it
+		seen[path] = true
+	}
+	var local []string
+	for _, path := range localConfigPaths(logs) {
+		if !seen[path] {
+			local = append(local, path)
+		}
+	}
+
+	paths := append([]string{}, explicit...)
+	paths = append(paths, local...)
+	paths = append(paths, user...)
+	paths = append(paths, dropInConfigPaths(home)...)
+
+	seen = make(map[string]bool)
+	res := make([]string, 0, len(paths))
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			abs = path
+		}
+		if !seen[abs] {
+			seen[abs] = true
+			res = append(res, path)
+		}
+	}
+	return res, nil
+}
+
+// loadConfig decodes the config in the file called path, noting path as the
+// origin of its rules.
+func loadConfig(path string) (*weaver.Config, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return decodeConfig(file, path)
+}
+
+// decodeConfig decodes the config in r, noting origin as the origin of its
+// rules.
+func decodeConfig(r io.Reader, origin string) (*weaver.Config, error) {
+	conf, err := weaver.DecodeConfig(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", origin, err)
+	}
+	conf.SetOrigin(origin)
+	return conf, nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gcla/logweaver/weaver"
+)
+
+// learnTarget returns the config that learned rules are added to - the user's
+// config if they have one, or else logweaver.toml in the user config directory.
+func learnTarget(home string) (string, error) {
+	if paths := userConfigPaths(home); len(paths) > 0 {
+		return paths[0], nil
+	}
+	dir := userConfigDir(home)
+	if dir == "" {
+		return "", fmt.Errorf("no home directory or XDG_CONFIG_HOME for a user config")
+	}
+	return filepath.Join(dir, "logweaver.toml"), nil
+}
+
+// learnedName returns a name for a rule learned from src, e.g. app for
+// app.log, that no rule in conf has - or "" if there's no such name.
+func learnedName(src *weaver.Source, conf *weaver.Config) string {
+	if src == nil || src.Name == weaver.StdinName {
+		return ""
+	}
+	base := strings.SplitN(src.Basename(), ".", 2)[0]
+	taken := make(map[string]bool)
+	for _, m := range conf.Match {
+		taken[m.Name] = true
+	}
+	name := base
+	for i := 2; taken[name]; i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	return name
+}
+
+// appendRule adds the rules in conf to the end of the config at path, with
+// a comment saying where they were learned from. If there's no config at path,
+// it's created, starting with the template for a user config.
+func appendRule(path string, conf *weaver.Config, from string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		template, err := weaver.OpenEmptyConfig()
+		if err != nil {
+			return err
+		}
+		defer template.Close()
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(file, template); err != nil {
+			file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(file, "\n# learned from %s\n", from); err != nil {
+		file.Close()
+		return err
+	}
+	if err := conf.WriteTOML(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// learnRules proposes a rule for each of logs, and for lines if given, and
+// asks whether to add each to the user's config. conf is the config in use, so
+// that a learned rule can be given a name of its own.
+func learnRules(logs []string, lines []string, conf *weaver.Config, home string, openOpts weaver.OpenOptions) int {
+	res := 0
+	target, err := learnTarget(home)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	type lesson struct {
+		from   string
+		src    *weaver.Source
+		lesson *weaver.Lesson
+	}
+	var lessons []lesson
+	if len(lines) > 0 {
+		l, err := weaver.Learn(lines)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --learn-line: %v\n", err)
+			return 1
+		}
+		lessons = append(lessons, lesson{from: "--learn-line", lesson: l})
+	}
+	if len(logs) > 0 {
+		// Only the first lines are needed
+		openOpts.Follow = false
+		srcs, err := weaver.OpenSources(logs, openOpts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		defer srcs.Close()
+		for _, src := range srcs {
+			l, err := weaver.LearnSource(src)
+			if err != nil {
+				// The other logs may still be learned from
+				fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
+				res = 1
+				continue
+			}
+			lessons = append(lessons, lesson{from: src.Name, src: src, lesson: l})
+		}
+	}
+
+	answers := bufio.NewReader(os.Stdin)
+	for _, l := range lessons {
+		rule := l.lesson.Rule
+		rule.Name = learnedName(l.src, conf)
+		if l.src != nil && l.src.Name != weaver.StdinName {
+			// Rotated copies too, e.g. app.log.1
+			rule.Files = []string{l.src.Basename() + "*"}
+		}
+		conf.Match = append(conf.Match, *rule)
+
+		fmt.Printf("%s\n", l.from)
+		if err := l.lesson.Write(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("\n")
+		if err := l.lesson.Config.WriteTOML(os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+
+		fmt.Printf("\nAdd this rule to %s? [y/N] ", target)
+		answer, err := answers.ReadString('\n')
+		if err != nil && err != io.EOF {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			if err := appendRule(target, l.lesson.Config, l.from); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: could not add the rule to %s: %v\n", target, err)
+				return 1
+			}
+			fmt.Printf("Added.\n\n")
+		default:
+			if err == io.EOF {
+				fmt.Printf("\n")
+			}
+			fmt.Printf("Not added.\n\n")
+		}
+	}
+	return res
+}
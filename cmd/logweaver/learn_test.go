@@ -0,0 +1,106 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gcla/logweaver/weaver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLearnedName(t *testing.T) {
+	conf := &weaver.Config{Match: []weaver.Match{{Name: "syslog"}, {Name: "app"}, {Name: "app-2"}}}
+	tests := []struct {
+		name string // of the source, if any
+		want string
+	}{
+		{"", ""},
+		{weaver.StdinName, ""},
+		{"/var/log/confd.log.1", "confd"},
+		{"/var/log/syslog", "syslog-2"},
+		{"logs/app.log", "app-3"},
+	}
+	for _, test := range tests {
+		var src *weaver.Source
+		if test.name != "" {
+			var err error
+			if src, err = weaver.NewSource(test.name, strings.NewReader("")); err != nil {
+				t.Fatal(err)
+			}
+		}
+		assert.Equal(t, test.want, learnedName(src, conf), test.name)
+	}
+}
+
+func TestLearnTarget(t *testing.T) {
+	home, err := ioutil.TempDir("", "logweaver-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	defer os.Setenv("XDG_CONFIG_HOME", os.Getenv("XDG_CONFIG_HOME"))
+	os.Unsetenv("XDG_CONFIG_HOME")
+
+	// Without a config, one is created in the user config directory...
+	target, err := learnTarget(home)
+	if assert.NoError(t, err) {
+		assert.Equal(t, filepath.Join(home, ".config", "logweaver", "logweaver.toml"), target)
+	}
+
+	// ...but an existing config is added to
+	old := filepath.Join(home, ".logweaver.toml")
+	if err := ioutil.WriteFile(old, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	target, err = learnTarget(home)
+	if assert.NoError(t, err) {
+		assert.Equal(t, old, target)
+	}
+
+	_, err = learnTarget("")
+	assert.Error(t, err)
+}
+
+func TestAppendRule(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logweaver-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "logweaver", "logweaver.toml")
+
+	for i, line := range []string{"2020-10-05 16:06:10,123 INFO started", "1601914000123 event"} {
+		l, err := weaver.Learn([]string{line})
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.Rule.Name = []string{"app", "events"}[i]
+		l.Rule.Files = []string{l.Rule.Name + ".log*"}
+		if !assert.NoError(t, appendRule(path, l.Config, "--learn-line")) {
+			return
+		}
+	}
+
+	text, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, strings.Count(string(text), "\n# learned from --learn-line\n"))
+
+	// The template the config starts from, then both rules, which still parse
+	// their examples
+	conf, err := loadConfig(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if assert.Len(t, conf.Match, 2) {
+		assert.Equal(t, "app", conf.Match[0].Name)
+		assert.Equal(t, []string{"app.log*"}, conf.Match[0].Files)
+		assert.Equal(t, "events", conf.Match[1].Name)
+		assert.Equal(t, "ms", conf.Match[1].Unit)
+	}
+	assert.Empty(t, conf.Check())
+}
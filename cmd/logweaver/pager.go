@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 
 	shellquote "github.com/kballard/go-shellquote"
@@ -29,7 +31,7 @@ func maybeExecWithPager(color bool) bool {
 		}
 
 		os.StartProcess("/bin/sh", []string{"/bin/sh", "-c", lm + fmt.Sprintf(" | %s", pager)}, &os.ProcAttr{
-			Files: []*os.File{os.Stdin, os.Stdout, os.Stderr},
+			Files: inheritedFiles(),
 			Env:   append(os.Environ(), "LOGWEAVER_USE_COLOR=true"),
 		})
 
@@ -45,3 +47,24 @@ func maybeExecWithPager(color bool) bool {
 	}
 	return false
 }
+
+// inheritedFiles returns the files the pager pipeline needs - stdin, stdout and
+// stderr, plus any /dev/fd/N arguments, e.g. from bash's process substitution,
+// at the same descriptor number.
+func inheritedFiles() []*os.File {
+	files := []*os.File{os.Stdin, os.Stdout, os.Stderr}
+	for _, arg := range os.Args[1:] {
+		if !strings.HasPrefix(arg, "/dev/fd/") {
+			continue
+		}
+		fd, err := strconv.Atoi(strings.TrimPrefix(arg, "/dev/fd/"))
+		if err != nil || fd < len(files) && files[fd] != nil {
+			continue
+		}
+		for len(files) <= fd {
+			files = append(files, nil)
+		}
+		files[fd] = os.NewFile(uintptr(fd), arg)
+	}
+	return files
+}
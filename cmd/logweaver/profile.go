@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gcla/logweaver/weaver"
+	flags "github.com/jessevdk/go-flags"
+)
+
+// Options a profile can't set - those that choose the config, and so the
+// profile, those that do something other than merge logs, and --follow, which
+// keeps running rather than finishing with the logs as they are
+var notInProfile = map[string]bool{
+	"help":                  true,
+	"profile":               true,
+	"config":                true,
+	"show-user-config":      true,
+	"show-default-config":   true,
+	"show-effective-config": true,
+	"check-config":          true,
+	"learn":                 true,
+	"learn-line":            true,
+	"explain":               true,
+	"follow":                true,
+	"color-env":             true,
+}
+
+// newParser returns a parser of command-line arguments into opts.
+func newParser(opts *Flags) *flags.Parser {
+	return flags.NewParser(opts, 0)
+}
+
+// negations removes the arguments that turn off a switch, e.g. --no-separator,
+// from args, and returns the rest along with the long names of the switches
+// turned off. Switches are off unless turned on, so these only matter with a
+// profile that turns them on.
+func negations(parser *flags.Parser, args []string) ([]string, map[string]bool) {
+	res := make([]string, 0, len(args))
+	negated := make(map[string]bool)
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "--no-") && parser.FindOptionByLongName(arg[2:]) == nil {
+			key := arg[len("--no-"):]
+			option := parser.FindOptionByLongName(key)
+			if option != nil && !notInProfile[key] && option.Field().Type.Kind() == reflect.Bool {
+				negated[key] = true
+				continue
+			}
+		}
+		res = append(res, arg)
+	}
+	return res, negated
+}
+
+// profileArgs returns the settings of profile as command-line arguments, for
+// those options not set already by parser, or turned off by negated - since
+// options given on the command line take precedence over the profile.
+func profileArgs(parser *flags.Parser, name string, profile weaver.Profile, negated map[string]bool) ([]string, error) {
+	keys := make([]string, 0, len(profile))
+	for key := range profile {
+		if key != weaver.ProfileRules && key != weaver.ProfileExcludeRules {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var res []string
+	for _, key := range keys {
+		option := parser.FindOptionByLongName(key)
+		if option == nil || notInProfile[key] {
+			return nil, fmt.Errorf("profile %s has unexpected setting %s", name, key)
+		}
+		if (option.IsSet() && !option.IsSetDefault()) || negated[key] {
+			continue
+		}
+		kind := option.Field().Type.Kind()
+		values, ok := profile[key].([]interface{})
+		if !ok {
+			values = []interface{}{profile[key]}
+		} else if kind != reflect.Slice {
+			return nil, fmt.Errorf("profile %s has a list for %s, which takes one value", name, key)
+		}
+		for _, value := range values {
+			switch v := value.(type) {
+			case bool:
+				// Switches can only be turned on
+				if kind == reflect.Bool {
+					if v {
+						res = append(res, "--"+key)
+					}
+					continue
+				}
+			case string, int64, float64:
+				if kind == reflect.Bool {
+					return nil, fmt.Errorf("profile %s has %v for %s, which is true or false", name, value, key)
+				}
+			default:
+				return nil, fmt.Errorf("profile %s has unexpected value %v for %s", name, value, key)
+			}
+			res = append(res, fmt.Sprintf("--%s=%v", key, value))
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gcla/logweaver/weaver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfileArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile weaver.Profile
+		args    []string // given on the command line
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "settings",
+			profile: weaver.Profile{
+				"timezone":  "Europe/Berlin",
+				"offset":    []interface{}{"10s,raslog*", "2m,dmesg*"},
+				"separator": true,
+				"alt-style": false,
+				"rules":     []interface{}{"iso"},
+			},
+			want: []string{"--offset=10s,raslog*", "--offset=2m,dmesg*", "--separator", "--timezone=Europe/Berlin"},
+		},
+		{
+			name:    "the command line takes precedence",
+			profile: weaver.Profile{"timezone": "Europe/Berlin", "offset": []interface{}{"10s,raslog*"}},
+			args:    []string{"--offset=1s,app.log"},
+			want:    []string{"--timezone=Europe/Berlin"},
+		},
+		{
+			name:    "a switch turned off",
+			profile: weaver.Profile{"separator": true},
+			args:    []string{"--no-separator"},
+			want:    nil,
+		},
+		{name: "unknown setting", profile: weaver.Profile{"colour": true}, wantErr: true},
+		{name: "a list for one value", profile: weaver.Profile{"timezone": []interface{}{"UTC"}}, wantErr: true},
+		{name: "not true or false", profile: weaver.Profile{"separator": "yes"}, wantErr: true},
+		{name: "choosing the config", profile: weaver.Profile{"config": []interface{}{"other.toml"}}, wantErr: true},
+		{name: "choosing the profile", profile: weaver.Profile{"profile": "other"}, wantErr: true},
+		{name: "explain", profile: weaver.Profile{"explain": true}, wantErr: true},
+		{name: "follow", profile: weaver.Profile{"follow": true}, wantErr: true},
+		{name: "learn", profile: weaver.Profile{"learn": true}, wantErr: true},
+		{name: "check-config", profile: weaver.Profile{"check-config": true}, wantErr: true},
+	}
+	for _, test := range tests {
+		var opts Flags
+		parser := newParser(&opts)
+		args, negated := negations(parser, append([]string{"logweaver"}, test.args...))
+		if _, err := parser.ParseArgs(args); err != nil {
+			t.Fatal(err)
+		}
+		got, err := profileArgs(parser, "test", test.profile, negated)
+		if test.wantErr {
+			assert.Error(t, err, test.name)
+		} else if assert.NoError(t, err, test.name) {
+			assert.Equal(t, test.want, got, test.name)
+		}
+	}
+}
+
+func TestNegations(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    []string
+		negated map[string]bool
+	}{
+		{
+			args:    []string{"logweaver", "--no-separator", "--no-alt-style", "app.log"},
+			want:    []string{"logweaver", "app.log"},
+			negated: map[string]bool{"separator": true, "alt-style": true},
+		},
+		{
+			// An option of its own, not a negation
+			args:    []string{"logweaver", "--no-timestamp", "app.log"},
+			want:    []string{"logweaver", "--no-timestamp", "app.log"},
+			negated: map[string]bool{},
+		},
+		{
+			// Only switches a profile can set can be turned off
+			args:    []string{"logweaver", "--no-timezone", "--no-follow", "--no-such-option", "app.log"},
+			want:    []string{"logweaver", "--no-timezone", "--no-follow", "--no-such-option", "app.log"},
+			negated: map[string]bool{},
+		},
+	}
+	for _, test := range tests {
+		var opts Flags
+		args, negated := negations(newParser(&opts), test.args)
+		assert.Equal(t, test.want, args, test.args)
+		assert.Equal(t, test.negated, negated, test.args)
+	}
+}
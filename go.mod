@@ -9,9 +9,12 @@ require (
 	github.com/gcla/termshark/v2 v2.1.1
 	github.com/jessevdk/go-flags v1.4.0
 	github.com/kballard/go-shellquote v0.0.0-20180428030007-95032a82bc51
+	github.com/klauspost/compress v1.11.4
 	github.com/lestrrat-go/strftime v1.0.3
 	github.com/logrusorgru/aurora v2.0.3+incompatible
 	github.com/logrusorgru/aurora/v3 v3.0.0 // indirect
+	github.com/pierrec/lz4/v4 v4.1.1
 	github.com/rakyll/statik v0.1.7
 	github.com/stretchr/testify v1.4.0
+	github.com/ulikunitz/xz v0.5.8
 )
@@ -0,0 +1,198 @@
+package weaver
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync/atomic"
+)
+
+// ArchiveSeparator separates the name of an archive from the name of a file
+// inside it, e.g. bundle.tgz!/var/log/syslog
+const ArchiveSeparator = "!"
+
+var zipMagic = []byte("PK\x03\x04")
+
+// openArchive returns a source for each regular file inside file, if it is a
+// tar archive - which may be compressed - or a zip archive. No files are
+// extracted; each source reads its member directly from the archive, or for a
+// compressed tar, from one temporary copy of the decompressed archive. If ok is
+// true, openArchive has taken ownership of file.
+func openArchive(name string, file *os.File, fi os.FileInfo) (srcs Sources, ok bool, err error) {
+	magic := make([]byte, len(zipMagic))
+	if n, _ := file.ReadAt(magic, 0); bytes.Equal(magic[:n], zipMagic) {
+		srcs, err = openZip(name, file, fi)
+		return srcs, true, err
+	}
+
+	compressed, err := isTar(name, file)
+	if err != nil || compressed == nil {
+		return nil, false, err
+	}
+	srcs, err = openTar(name, file, *compressed)
+	return srcs, true, err
+}
+
+// isTar returns nil if file is not a tar archive. Otherwise it returns whether
+// or not the archive is compressed.
+func isTar(name string, file *os.File) (*bool, error) {
+	defer file.Seek(0, io.SeekStart)
+
+	var r io.Reader = file
+	compressed := false
+	magic := make([]byte, maxMagicLen())
+	if n, _ := file.ReadAt(magic, 0); sniff(magic[:n]) != nil {
+		dec, closer, err := decompress(name, file)
+		if err != nil {
+			return nil, nil
+		}
+		if closer != nil {
+			defer closer.Close()
+		}
+		r = dec
+		compressed = true
+	}
+
+	// The ustar magic, at offset 257, is shared by POSIX and GNU archives
+	block := make([]byte, 263)
+	if _, err := io.ReadFull(r, block); err != nil || !bytes.Equal(block[257:262], []byte("ustar")) {
+		return nil, nil
+	}
+	return &compressed, nil
+}
+
+func memberName(archive string, member string) string {
+	return archive + ArchiveSeparator + path.Clean("/"+member)
+}
+
+func openZip(name string, file *os.File, fi os.FileInfo) (Sources, error) {
+	zr, err := zip.NewReader(file, fi.Size())
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error reading zip archive %s: %w", name, err)
+	}
+
+	shared := &sharedFile{file: file}
+	defer shared.ref().Close() // until every member has its own reference
+
+	res := make(Sources, 0, len(zr.File))
+	for _, zf := range zr.File {
+		if !zf.Mode().IsRegular() {
+			continue
+		}
+		zf := zf
+		mname := memberName(name, zf.Name)
+		src := newLazySource(mname, func() (io.ReadCloser, error) {
+			return zf.Open()
+		}, shared.ref())
+		src.Modified = zf.Modified
+		res = append(res, src)
+	}
+	return res, nil
+}
+
+func openTar(name string, file *os.File, compressed bool) (Sources, error) {
+	var r io.Reader = file
+	data := &sharedFile{file: file}
+	if compressed {
+		dec, closer, err := decompress(name, file)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		// A compressed archive can only be read from the start, so it is decompressed
+		// once, into a temporary file that each member then reads its data from.
+		tmp, err := ioutil.TempFile("", "logweaver-")
+		if err != nil {
+			if closer != nil {
+				closer.Close()
+			}
+			file.Close()
+			return nil, fmt.Errorf("error reading tar archive %s: %w", name, err)
+		}
+		defer func() {
+			if closer != nil {
+				closer.Close()
+			}
+			file.Close()
+		}()
+		r = io.TeeReader(dec, tmp)
+		data = &sharedFile{file: tmp, remove: true}
+	}
+	counter := &countingReader{r: r}
+	tr := tar.NewReader(counter)
+
+	defer data.ref().Close() // until every member has its own reference
+
+	res := make(Sources, 0, 128)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			res.Close()
+			return nil, fmt.Errorf("error reading tar archive %s: %w", name, err)
+		}
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeRegA {
+			continue
+		}
+		// The member's data is stored as-is, straight after its header
+		off, size := counter.n, hdr.Size
+		src := newLazySource(memberName(name, hdr.Name), func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(io.NewSectionReader(data.file, off, size)), nil
+		}, data.ref())
+		src.Modified = hdr.ModTime
+		res = append(res, src)
+	}
+	return res, nil
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// sharedFile is an archive file read by several sources. It is closed when
+// the last of them is closed, and removed too if it is temporary.
+type sharedFile struct {
+	file   *os.File
+	remove bool
+	refs   int32
+}
+
+func (s *sharedFile) ref() io.Closer {
+	atomic.AddInt32(&s.refs, 1)
+	var closed int32
+	return closerFunc(func() error {
+		if !atomic.CompareAndSwapInt32(&closed, 0, 1) {
+			return nil
+		}
+		if atomic.AddInt32(&s.refs, -1) == 0 {
+			err := s.file.Close()
+			if s.remove {
+				os.Remove(s.file.Name())
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+type closerFunc func() error
+
+func (c closerFunc) Close() error {
+	return c()
+}
@@ -0,0 +1,195 @@
+package weaver
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testMember struct {
+	name string
+	text string // for a regular file
+	dir  bool
+}
+
+var testMembers = []testMember{
+	{name: "var/log/", dir: true},
+	{name: "var/log/app.log", text: "2020-10-05 16:00:00 app\n"},
+	{name: "var/log/db.log", text: "2020-10-05 16:00:01 db\n"},
+	{name: "./notes.txt", text: "notes\n"},
+}
+
+var testModified = time.Date(2020, time.October, 5, 17, 0, 0, 0, time.UTC)
+
+func tarArchive(t *testing.T, members []testMember) []byte {
+	var b bytes.Buffer
+	tw := tar.NewWriter(&b)
+	for _, m := range members {
+		hdr := &tar.Header{Name: m.name, Mode: 0644, Size: int64(len(m.text)), ModTime: testModified, Typeflag: tar.TypeReg, Format: tar.FormatPAX}
+		if m.dir {
+			hdr.Typeflag, hdr.Mode = tar.TypeDir, 0755
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(m.text))
+	}
+	// A link isn't read as a log
+	tw.WriteHeader(&tar.Header{Name: "var/log/link.log", Linkname: "app.log", Typeflag: tar.TypeSymlink, ModTime: testModified})
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func gzipData(t *testing.T, data []byte) []byte {
+	var b bytes.Buffer
+	zw := gzip.NewWriter(&b)
+	zw.Write(data)
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func zipArchive(t *testing.T, members []testMember) []byte {
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	for _, m := range members {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: strings.TrimPrefix(m.name, "./"), Method: zip.Deflate, Modified: testModified})
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(m.text))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+// readSources returns the name and contents of each source.
+func readSources(t *testing.T, srcs Sources) []string {
+	var res []string
+	for _, src := range srcs {
+		data, err := ioutil.ReadAll(src.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res = append(res, src.Name+": "+string(data))
+	}
+	return res
+}
+
+func TestOpenArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logweaver-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Temporary files, for compressed tars, go here so that they can be checked for
+	tmp := filepath.Join(dir, "tmp")
+	if err := os.Mkdir(tmp, 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Setenv("TMPDIR", os.Getenv("TMPDIR"))
+	os.Setenv("TMPDIR", tmp)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"bundle.tar", tarArchive(t, testMembers)},
+		{"bundle.tgz", gzipData(t, tarArchive(t, testMembers))},
+		{"bundle.zip", zipArchive(t, testMembers)},
+	}
+	for _, test := range tests {
+		name := filepath.Join(dir, test.name)
+		if err := ioutil.WriteFile(name, test.data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		srcs, err := OpenSources([]string{name}, OpenOptions{})
+		if !assert.NoError(t, err, test.name) {
+			continue
+		}
+		want := []string{
+			name + "!/var/log/app.log: 2020-10-05 16:00:00 app\n",
+			name + "!/var/log/db.log: 2020-10-05 16:00:01 db\n",
+			name + "!/notes.txt: notes\n",
+		}
+		assert.Equal(t, want, readSources(t, srcs), test.name)
+		for _, src := range srcs {
+			assert.True(t, testModified.Equal(src.Modified), "%s: modified %v", src.Name, src.Modified)
+		}
+		srcs.Close()
+
+		left, _ := ioutil.ReadDir(tmp)
+		assert.Empty(t, left, "%s: temporary files left behind", test.name)
+	}
+}
+
+func TestOpenArchiveStdin(t *testing.T) {
+	file, err := ioutil.TempFile("", "logweaver-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.Write(gzipData(t, tarArchive(t, testMembers)))
+	file.Seek(0, 0)
+
+	withStdin(file, func() {
+		srcs, err := OpenSources([]string{StdinName}, OpenOptions{})
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer srcs.Close()
+		assert.Equal(t, []string{
+			"-!/var/log/app.log: 2020-10-05 16:00:00 app\n",
+			"-!/var/log/db.log: 2020-10-05 16:00:01 db\n",
+			"-!/notes.txt: notes\n",
+		}, readSources(t, srcs))
+	})
+}
+
+func TestOpenArchiveErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logweaver-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Cut short in the middle of the second member
+	data := tarArchive(t, testMembers)
+	bad := filepath.Join(dir, "logs", "bad.tgz")
+	os.Mkdir(filepath.Dir(bad), 0755)
+	if err := ioutil.WriteFile(bad, gzipData(t, data[:3*512+10]), 0644); err != nil {
+		t.Fatal(err)
+	}
+	good := filepath.Join(dir, "logs", "good.log")
+	if err := ioutil.WriteFile(good, []byte("2020-10-05 16:00:00 good\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Named directly, the archive must be read
+	_, err = OpenSources([]string{bad}, OpenOptions{})
+	assert.Error(t, err)
+
+	// Found in a directory, it's skipped with a warning
+	var warnings bytes.Buffer
+	srcs, err := OpenSources([]string{filepath.Dir(bad)}, OpenOptions{Warnings: &warnings})
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{good + ": 2020-10-05 16:00:00 good\n"}, readSources(t, srcs))
+		assert.Contains(t, warnings.String(), "Warning, skipping "+bad+": ")
+		srcs.Close()
+	}
+}
@@ -0,0 +1,245 @@
+package weaver
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SinceBoot is for Match.Since - the timestamp is a count of Match.Unit since
+// the system booted, as in dmesg output e.g. [12345.678901] eth0: link up.
+const SinceBoot = "boot"
+
+// Evidence of boots this close together is taken to be of the same boot.
+const sameBoot = 5 * time.Minute
+
+var (
+	// The first message the kernel logs
+	linuxVersionRE = regexp.MustCompile(`Linux version [0-9]`)
+	// The time since boot of a kernel message, e.g. in syslog's copy of it
+	kernelUptimeRE = regexp.MustCompile(`\[ *([0-9]+\.[0-9]+)\] `)
+	// Logged by systemd once the system has booted, e.g. Startup finished in 2.1s (kernel) + 7.1s (userspace) = 9.2s.
+	startupFinishedRE = regexp.MustCompile(`Startup finished in .*\(kernel\).* = (.+?)\.?$`)
+	// The boot time in /proc/stat, in seconds since the Unix epoch
+	btimeRE = regexp.MustCompile(`^btime ([0-9]+)$`)
+)
+
+// Kinds of evidence of a boot, best last
+const (
+	bootFromLinuxVersion = iota
+	bootFromStartupFinished
+	bootFromBtime
+)
+
+// bootEvent is evidence, found in a log, of when a system booted.
+type bootEvent struct {
+	tm     time.Time
+	kind   int    // one of the bootFrom constants
+	bundle string // the directory or archive holding the log
+}
+
+// timespanUnits are the units of durations written by systemd.
+var timespanUnits = map[string]time.Duration{
+	"us":  time.Microsecond,
+	"ms":  time.Millisecond,
+	"s":   time.Second,
+	"min": time.Minute,
+	"h":   time.Hour,
+	"d":   24 * time.Hour,
+}
+
+// parseTimespan parses a duration as written by systemd, e.g. 1min 8.133s.
+func parseTimespan(span string) (time.Duration, error) {
+	var res time.Duration
+	fields := strings.Fields(span)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("could not parse '%s' as a timespan", span)
+	}
+	for _, field := range fields {
+		i := strings.IndexFunc(field, func(r rune) bool {
+			return (r < '0' || r > '9') && r != '.'
+		})
+		if i <= 0 {
+			return 0, fmt.Errorf("could not parse '%s' as a timespan", span)
+		}
+		unit, ok := timespanUnits[field[i:]]
+		if !ok {
+			return 0, fmt.Errorf("unexpected unit '%s' in timespan '%s'", field[i:], span)
+		}
+		n, err := strconv.ParseFloat(field[:i], 64)
+		if err != nil {
+			return 0, fmt.Errorf("could not parse '%s' as a timespan: %w", span, err)
+		}
+		res += time.Duration(math.Round(n * float64(unit)))
+	}
+	return res, nil
+}
+
+// fromBoot returns tm, a timestamp parsed by rule. If the rule counts from
+// boot, tm is moved from the Unix epoch to the boot time of the parser's
+// source - or left as it is if that isn't known.
+func (p *parser) fromBoot(rule *Match, tm time.Time) time.Time {
+	if rule.Since != SinceBoot || p.src.Booted.IsZero() {
+		return tm
+	}
+	return p.src.Booted.Add(tm.Sub(time.Unix(0, 0)))
+}
+
+// bootEvents reads the parser's source again from the start, and returns the
+// evidence it holds of when its system booted. Only a btime line can be used
+// if no rule was found for the source.
+func (p *parser) bootEvents() ([]bootEvent, error) {
+	r, err := p.src.reopen()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var rule *Match
+	if p.detected != -1 {
+		rule = p.rules[p.detected]
+	}
+	bundle := bundleOf(p.src.Name)
+	var res []bootEvent
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 65536*16), 65536*16)
+	for sc.Scan() {
+		line := sc.Text()
+		if m := btimeRE.FindStringSubmatch(line); m != nil {
+			if secs, err := strconv.ParseInt(m[1], 10, 64); err == nil {
+				res = append(res, bootEvent{tm: time.Unix(secs, 0).UTC(), kind: bootFromBtime, bundle: bundle})
+			}
+			continue
+		}
+		if rule == nil {
+			continue
+		}
+		var kind int
+		var sinceBoot time.Duration
+		if m := startupFinishedRE.FindStringSubmatch(line); m != nil {
+			span, err := parseTimespan(m[1])
+			if err != nil {
+				continue
+			}
+			kind, sinceBoot = bootFromStartupFinished, span
+		} else if linuxVersionRE.MatchString(line) {
+			kind = bootFromLinuxVersion
+			if m := kernelUptimeRE.FindStringSubmatch(line); m != nil {
+				if uptime, err := parseEpoch(m[1], UnitSeconds); err == nil {
+					sinceBoot = uptime.Sub(time.Unix(0, 0))
+				}
+			}
+		} else {
+			continue
+		}
+		if tm, ok := p.wallTime(rule, line); ok {
+			res = append(res, bootEvent{tm: tm.Add(-sinceBoot), kind: kind, bundle: bundle})
+		}
+	}
+	return res, sc.Err()
+}
+
+// wallTime returns the timestamp of line, parsed by rule, the rule chosen for
+// the parser's source, as it would be when merging - except that a year is
+// inferred for each line on its own.
+func (p *parser) wallTime(rule *Match, line string) (time.Time, bool) {
+	if rule.Since == SinceBoot {
+		return time.Time{}, false
+	}
+	start, end, ok := rule.timestampSpan(rule.re.FindStringSubmatchIndex(line))
+	if !ok {
+		return time.Time{}, false
+	}
+	tm, _, err := rule.parseTimestamp(line[start:end], p.src.location(rule), p.abbrevs)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if tm.Year() == 0 {
+		tm = tm.AddDate(p.yearHint.year(tm), 0, 0)
+	}
+	return tm.Add(p.src.Offset), true
+}
+
+// latestBoot returns the time of the latest boot among events. Where there
+// are several pieces of evidence of that boot, the best kind is used.
+func latestBoot(events []bootEvent) (time.Time, bool) {
+	if len(events) == 0 {
+		return time.Time{}, false
+	}
+	latest := events[0]
+	for _, e := range events[1:] {
+		if e.tm.After(latest.tm) {
+			latest = e
+		}
+	}
+	best := latest
+	for _, e := range events {
+		if latest.tm.Sub(e.tm) < sameBoot && e.kind > best.kind {
+			best = e
+		}
+	}
+	return best.tm, true
+}
+
+// findBootTimes sets the boot time of each of states whose rule counts from
+// boot, if it isn't already known, from the latest boot found in the other
+// sources - those in the same directory or archive if they have any evidence
+// of one.
+func (m *Merger) findBootTimes(states []*state) {
+	var needed []*state
+	for _, s := range states {
+		p := s.parser
+		if p.detected != -1 && p.rules[p.detected].Since == SinceBoot && s.src.Booted.IsZero() {
+			needed = append(needed, s)
+		}
+	}
+	if len(needed) == 0 {
+		return
+	}
+
+	found := make([][]bootEvent, len(states))
+	errs := make([]error, len(states))
+	var wg sync.WaitGroup
+	for i, s := range states {
+		p := s.parser
+		if s.src.reopen == nil || (p.detected != -1 && p.rules[p.detected].Since == SinceBoot) {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, p *parser) {
+			defer wg.Done()
+			found[i], errs[i] = p.bootEvents()
+		}(i, p)
+	}
+	wg.Wait()
+
+	var events []bootEvent
+	for i, err := range errs {
+		if err != nil {
+			m.warnf("Warning: problem looking for the boot time in %s: %v\n", states[i].src.Name, err)
+		}
+		events = append(events, found[i]...)
+	}
+	for _, s := range needed {
+		bundle := bundleOf(s.src.Name)
+		var local []bootEvent
+		for _, e := range events {
+			if e.bundle == bundle {
+				local = append(local, e)
+			}
+		}
+		if len(local) == 0 {
+			local = events
+		}
+		if tm, ok := latestBoot(local); ok {
+			s.src.Booted = tm
+		} else {
+			m.warnf("Warning: boot time of %s not known - its times since boot are shown as times since 1970\n", s.src.Name)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package weaver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTimespan(t *testing.T) {
+	tests := []struct {
+		span string
+		want time.Duration
+	}{
+		{"8.133s", 8133 * time.Millisecond},
+		{"1min 8.133s", time.Minute + 8133*time.Millisecond},
+		{"2d 3h 4min 5s", 2*24*time.Hour + 3*time.Hour + 4*time.Minute + 5*time.Second},
+		{"500ms", 500 * time.Millisecond},
+		{"250us", 250 * time.Microsecond},
+		{" 1h ", time.Hour},
+	}
+	for _, test := range tests {
+		got, err := parseTimespan(test.span)
+		if assert.NoError(t, err, test.span) {
+			assert.Equal(t, test.want, got, test.span)
+		}
+	}
+}
+
+func TestParseTimespanErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"s",
+		"5",
+		"5 weeks",
+		"1.2.3s",
+		"1min x",
+	}
+	for _, span := range tests {
+		_, err := parseTimespan(span)
+		assert.Error(t, err, span)
+	}
+}
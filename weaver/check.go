@@ -0,0 +1,97 @@
+package weaver
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// RuleProblem is something wrong with a rule, found by Config.Check.
+type RuleProblem struct {
+	Rule    *Match
+	Problem string
+}
+
+func (p *RuleProblem) Error() string {
+	if p.Rule.Origin != "" {
+		return fmt.Sprintf("rule %s in %s: %s", p.Rule.describe(), p.Rule.Origin, p.Problem)
+	}
+	return fmt.Sprintf("rule %s: %s", p.Rule.describe(), p.Problem)
+}
+
+// Check parses the example line of each rule that has one, and returns the
+// problems found - an example the rule doesn't match or can't parse, or parses
+// only with dateparse rather than its formats, or parses as a different time
+// from the rule's Expected. A rule is also reported if an earlier rule gets a
+// timestamp from its example, since the earlier rule would be chosen for a log
+// of lines like it. Disabled rules are not checked.
+func (c *Config) Check() []*RuleProblem {
+	abbrevs := newAbbreviations(c.offsets)
+	rules := make([]*Match, 0, len(c.Match))
+	for i := range c.Match {
+		if !c.Match[i].Disabled {
+			rules = append(rules, &c.Match[i])
+		}
+	}
+	// The order rules are tried in - see rulesFor
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].Priority > rules[j].Priority
+	})
+
+	var res []*RuleProblem
+	for i, m := range rules {
+		problem := func(format string, args ...interface{}) {
+			res = append(res, &RuleProblem{Rule: m, Problem: fmt.Sprintf(format, args...)})
+		}
+		if m.Example == "" {
+			if m.Expected != "" {
+				problem("has an expected time but no example")
+			}
+			continue
+		}
+
+		start, end, ok := m.timestampSpan(m.re.FindStringSubmatchIndex(m.Example))
+		if !ok {
+			problem("regex doesn't match example %s", m.Example)
+			continue
+		}
+		ts := m.Example[start:end]
+		tm, guessed, err := m.parseTimestamp(ts, m.loc, abbrevs)
+		switch {
+		case err != nil:
+			problem("could not parse timestamp '%s' in example: %v", ts, err)
+			continue
+		case guessed && len(m.layouts) > 0:
+			problem("timestamp '%s' in example doesn't match any format, and was only parsed by guesswork", ts)
+		}
+		if m.Expected != "" && !tm.Equal(m.expected) {
+			problem("example parses as %s, expected %s", tm.Format(time.RFC3339Nano), m.Expected)
+		}
+
+		for _, earlier := range rules[:i] {
+			if !earlier.mayShadow(m) {
+				continue
+			}
+			if _, _, ok := earlier.timestamp(m.Example); ok {
+				problem("shadowed by earlier rule %s, which also gets a timestamp from the example", earlier.describe())
+				break
+			}
+		}
+	}
+	return res
+}
+
+// mayShadow returns true if m applies to every file that other does, going by
+// their Files globs.
+func (m *Match) mayShadow(other *Match) bool {
+	return len(m.Files) == 0 || reflect.DeepEqual(m.Files, other.Files)
+}
+
+// describe returns the rule's name, or its regex if it has none.
+func (m *Match) describe() string {
+	if m.Name != "" {
+		return m.Name
+	}
+	return m.Match
+}
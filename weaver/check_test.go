@@ -0,0 +1,165 @@
+package weaver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules string
+		want  []string
+	}{
+		{
+			name: "passes",
+			rules: `
+[[match]]
+name = 'iso'
+match = '^(\d{4}-\d\d-\d\d \d\d:\d\d:\d\d) '
+format = '2006-01-02 15:04:05'
+example = '2020-10-05 16:00:00 started'
+expected = '2020-10-05T16:00:00Z'
+`,
+		},
+		{
+			name: "no match",
+			rules: `
+[[match]]
+name = 'iso'
+match = '^(\d{4}-\d\d-\d\d \d\d:\d\d:\d\d) '
+format = '2006-01-02 15:04:05'
+example = '[2020-10-05 16:00:00] started'
+`,
+			want: []string{"rule iso: regex doesn't match example [2020-10-05 16:00:00] started"},
+		},
+		{
+			name: "wrong time",
+			rules: `
+[[match]]
+name = 'iso'
+match = '^(\d{4}-\d\d-\d\d \d\d:\d\d:\d\d) '
+format = '2006-01-02 15:04:05'
+example = '2020-10-05 16:00:00 started'
+expected = '2020-10-05T17:00:00Z'
+`,
+			want: []string{"rule iso: example parses as 2020-10-05T16:00:00Z, expected 2020-10-05T17:00:00Z"},
+		},
+		{
+			name: "guessed",
+			rules: `
+[[match]]
+name = 'iso'
+match = '^(\S+ \S+) '
+format = '2006-01-02 15:04:05'
+example = '2020/10/05 16:00:00 started'
+`,
+			want: []string{"rule iso: timestamp '2020/10/05 16:00:00' in example doesn't match any format, and was only parsed by guesswork"},
+		},
+		{
+			name: "expected without example",
+			rules: `
+[[match]]
+match = '^(\S+) '
+format = '2006'
+expected = '2020-10-05T17:00:00Z'
+`,
+			want: []string{`rule ^(\S+) : has an expected time but no example`},
+		},
+		{
+			name: "shadowed",
+			rules: `
+[[match]]
+name = 'loose'
+match = '^(\S+ \S+) '
+format = '2006-01-02 15:04:05'
+
+[[match]]
+name = 'iso'
+match = '^(\d{4}-\d\d-\d\d \d\d:\d\d:\d\d) '
+format = '2006-01-02 15:04:05'
+example = '2020-10-05 16:00:00 started'
+`,
+			want: []string{"rule iso: shadowed by earlier rule loose, which also gets a timestamp from the example"},
+		},
+		{
+			name: "not shadowed by a rule for other files, or one of lower priority",
+			rules: `
+[[match]]
+name = 'loose'
+match = '^(\S+ \S+) '
+format = '2006-01-02 15:04:05'
+files = ['other.log']
+
+[[match]]
+name = 'looser'
+match = '^(\S+ \S+) '
+format = '2006-01-02 15:04:05'
+priority = -1
+
+[[match]]
+name = 'iso'
+match = '^(\d{4}-\d\d-\d\d \d\d:\d\d:\d\d) '
+format = '2006-01-02 15:04:05'
+example = '2020-10-05 16:00:00 started'
+`,
+		},
+		{
+			name: "disabled",
+			rules: `
+[[match]]
+name = 'iso'
+match = '^(\d{4}-\d\d-\d\d \d\d:\d\d:\d\d) '
+format = '2006-01-02 15:04:05'
+example = 'no timestamp'
+disabled = true
+`,
+		},
+	}
+	for _, test := range tests {
+		conf, err := DecodeConfig(strings.NewReader(test.rules))
+		if !assert.NoError(t, err, test.name) {
+			continue
+		}
+		var got []string
+		for _, problem := range conf.Check() {
+			got = append(got, problem.Error())
+		}
+		assert.Equal(t, test.want, got, test.name)
+	}
+}
+
+func TestCheckOrigin(t *testing.T) {
+	conf, err := DecodeConfig(strings.NewReader(`
+[[match]]
+name = 'iso'
+match = '^(\d{4}-\d\d-\d\d \d\d:\d\d:\d\d) '
+format = '2006-01-02 15:04:05'
+example = 'no timestamp'
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.SetOrigin("/home/me/.logweaver.toml")
+	problems := conf.Check()
+	if assert.Len(t, problems, 1) {
+		assert.EqualError(t, problems[0], "rule iso in /home/me/.logweaver.toml: regex doesn't match example no timestamp")
+	}
+}
+
+func TestCheckDefaultConfig(t *testing.T) {
+	r, err := OpenDefaultConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	conf, err := DecodeConfig(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, problem := range conf.Check() {
+		t.Error(problem)
+	}
+}
@@ -0,0 +1,168 @@
+package weaver
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+	"github.com/ulikunitz/xz"
+)
+
+// Compressed streams within compressed streams are unwrapped up to this depth.
+const maxCompressionDepth = 4
+
+// Decompressor recognises a compressed stream by its first few bytes, and
+// decompresses it.
+type Decompressor struct {
+	Name       string                                   // e.g. gzip
+	Magic      []byte                                   // the bytes every stream in this format starts with
+	Extensions []string                                 // file name extensions for this format e.g. .gz
+	Open       func(r io.Reader) (io.ReadCloser, error) // returns a reader for the decompressed stream
+}
+
+var decompressors = []Decompressor{
+	{
+		Name:       "gzip",
+		Extensions: []string{".gz"},
+		Magic:      []byte{0x1f, 0x8b},
+		Open: func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+	},
+	{
+		Name:       "bzip2",
+		Extensions: []string{".bz2"},
+		Magic:      []byte("BZh"),
+		Open: func(r io.Reader) (io.ReadCloser, error) {
+			return ioutil.NopCloser(bzip2.NewReader(r)), nil
+		},
+	},
+	{
+		Name:       "xz",
+		Extensions: []string{".xz"},
+		Magic:      []byte{0xfd, '7', 'z', 'X', 'Z', 0x00},
+		Open: func(r io.Reader) (io.ReadCloser, error) {
+			xr, err := xz.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			return ioutil.NopCloser(xr), nil
+		},
+	},
+	{
+		Name:       "zstd",
+		Extensions: []string{".zst"},
+		Magic:      []byte{0x28, 0xb5, 0x2f, 0xfd},
+		Open: func(r io.Reader) (io.ReadCloser, error) {
+			zr, err := zstd.NewReader(r)
+			if err != nil {
+				return nil, err
+			}
+			return zr.IOReadCloser(), nil
+		},
+	},
+	{
+		Name:       "lz4",
+		Extensions: []string{".lz4"},
+		Magic:      []byte{0x04, 0x22, 0x4d, 0x18},
+		Open: func(r io.Reader) (io.ReadCloser, error) {
+			return ioutil.NopCloser(lz4.NewReader(r)), nil
+		},
+	},
+}
+
+// RegisterDecompressor adds a compression format to those recognised by
+// NewSource and OpenSources. It should be called before any sources are
+// opened, e.g. from an init function.
+func RegisterDecompressor(d Decompressor) {
+	decompressors = append(decompressors, d)
+}
+
+// maxMagicLen returns the number of bytes needed to recognise any registered
+// format.
+func maxMagicLen() int {
+	res := 0
+	for _, d := range decompressors {
+		if len(d.Magic) > res {
+			res = len(d.Magic)
+		}
+	}
+	return res
+}
+
+// trimCompressionExt returns name without its extension, if the extension is
+// that of a registered compression format.
+func trimCompressionExt(name string) string {
+	for _, d := range decompressors {
+		for _, ext := range d.Extensions {
+			if strings.HasSuffix(name, ext) {
+				return strings.TrimSuffix(name, ext)
+			}
+		}
+	}
+	return name
+}
+
+// sniff returns the format that the stream starting with magic is compressed
+// with, or nil if it isn't recognised.
+func sniff(magic []byte) *Decompressor {
+	for i, d := range decompressors {
+		if len(d.Magic) > 0 && bytes.HasPrefix(magic, d.Magic) {
+			return &decompressors[i]
+		}
+	}
+	return nil
+}
+
+// decompress returns a reader for the contents of r, decompressing it if it
+// is in a recognised format - repeatedly, if the decompressed stream is itself
+// compressed. The closer, if not nil, releases the decompressors.
+func decompress(name string, r io.Reader) (io.Reader, io.Closer, error) {
+	var closers multiCloser
+	for depth := 0; ; depth++ {
+		breader := bufio.NewReaderSize(r, 65536*8)
+
+		// Peek returns what it can, along with an error, for short streams
+		testBytes, _ := breader.Peek(maxMagicLen())
+		d := sniff(testBytes)
+		if d == nil || depth == maxCompressionDepth {
+			return breader, closers.orNil(), nil
+		}
+
+		dreader, err := d.Open(breader)
+		if err != nil {
+			closers.Close()
+			return nil, nil, fmt.Errorf("error opening %s compressed file %s: %w", d.Name, name, err)
+		}
+		closers = append(closers, dreader)
+		r = dreader
+	}
+}
+
+// multiCloser closes a stack of decompressors, innermost first.
+type multiCloser []io.Closer
+
+func (m multiCloser) Close() error {
+	var err error
+	for i := len(m) - 1; i >= 0; i-- {
+		err2 := m[i].Close()
+		if err2 != nil {
+			err = err2
+		}
+	}
+	return err
+}
+
+func (m multiCloser) orNil() io.Closer {
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
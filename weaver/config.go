@@ -0,0 +1,513 @@
+package weaver
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/araddon/dateparse"
+	"github.com/rakyll/statik/fs"
+
+	_ "github.com/gcla/logweaver/assets/statik"
+)
+
+// Config is a list of rules for extracting timestamps from log lines. It is
+// decoded from TOML - see assets/logweaver.toml for the built-in rules.
+//
+// Abbreviations maps timezone abbreviations to offsets from UTC, e.g. IST to
+// +01:00, replacing or adding to the built-in table used when a timestamp
+// names its timezone by abbreviation.
+//
+// Profile holds named sets of settings, e.g. [profile.slx-supportsave].
+type Config struct {
+	Match          []Match
+	Timezone       []SourceTimezone
+	Abbreviations  map[string]string
+	Profile        map[string]Profile
+	offsets        map[string]int
+	profileOrigins map[string]string // where each profile came from - see Config.SetOrigin
+}
+
+// Profile is a named set of settings, for one kind of investigation e.g. of an
+// SLX supportsave. The settings named by the Profile constants choose a subset
+// of the rules; the others are for the program using the config - those for
+// logweaver are named after its command-line options, e.g. timezone =
+// 'Europe/Berlin' or offset = ['10s,slx*.log'].
+type Profile map[string]interface{}
+
+// Settings of a Profile
+const (
+	ProfileRules        = "rules"         // if set, the names of the only rules to use
+	ProfileExcludeRules = "exclude_rules" // the names of rules not to use
+)
+
+// SourceTimezone sets the timezone of timestamps that don't include one, for
+// the sources with names matching Files, a glob e.g. *mariadb*.log. This takes
+// precedence over the timezone of the rule.
+type SourceTimezone struct {
+	Files  string
+	Zone   string
+	Origin string `toml:"-"` // where the setting came from - see Config.SetOrigin
+	loc    *time.Location
+}
+
+// Orders for Match.Order
+const (
+	OrderAuto       = "auto"       // detect from the first lines of each file; the default
+	OrderAscending  = "ascending"  // oldest line first, as in most logs
+	OrderDescending = "descending" // newest line first, e.g. the output of last(1)
+)
+
+// Match is a single timestamp-extraction rule. The group of the regex named
+// ts - or otherwise group #1 - is the timestamp, which is parsed using Format
+// and then each of Formats, until one succeeds, and otherwise by dateparse.
+// Formats are Go layouts, or in the style of strptime(3) if they contain a %.
+// Other named groups, e.g. level, host, pid or msg, are attached to each
+// record as fields.
+//
+// Rules are tried in order of Priority, highest first, and otherwise in the
+// order they are given. A rule only applies to the sources matching Files, if
+// set, and not matching ExcludeFiles. A rule replaces any later rule with the
+// same Name e.g. a user's rule can replace or disable a built-in rule.
+//
+// If Start or Delimiter is set, lines are grouped into multi-line records -
+// e.g. a Java stack trace along with the line that logged it - which are
+// merged as a unit, under the timestamp of the record's first line.
+//
+// A line with an earlier timestamp than the line before is normally taken to
+// continue that line. If ReorderWindow or ReorderLines is set, lines are held
+// back so that those only a little out of order - e.g. written by different
+// threads - can be put back in order first.
+type Match struct {
+	Match         string
+	Format        string
+	Formats       []string // further formats, tried in order if Format doesn't parse the timestamp
+	Name          string   // if set, names the rule, so a rule earlier in the config can replace it
+	Files         []string // if set, globs e.g. confd.log* - the rule only applies to sources with names matching one of these
+	ExcludeFiles  []string `toml:"exclude_files"` // globs for sources the rule doesn't apply to
+	Priority      int      // rules with a higher priority are tried first
+	Disabled      bool     // if true, the rule is never used - with Name, this disables a built-in rule
+	Order         string   // one of the Order constants - empty means OrderAuto
+	Start         string   // if set, a regex matching the first line of each record
+	Delimiter     string   // if set, a regex matching the last line of each record e.g. ^$ for a blank line
+	Timezone      string   // if set, e.g. Europe/Berlin, the timezone of timestamps that don't include one - otherwise UTC
+	Unit          string   // if set, one of the Unit constants - the timestamp is a count of these since the Unix epoch, instead of using Format
+	Since         string   // if SinceBoot, the timestamp is a count of Unit since the system booted, rather than since the Unix epoch
+	ReorderWindow string   `toml:"reorder_window"` // if set, e.g. 500ms, lines up to this much earlier than the latest line are put back in order
+	ReorderLines  int      `toml:"reorder_lines"`  // if set, lines up to this many lines out of place are put back in order
+	Example       string   // if set, a line the rule should parse - see Config.Check
+	Expected      string   // if set, the time the rule should parse Example as, in RFC 3339 format e.g. 2020-09-28T18:00:36Z
+	Origin        string   `toml:"-"` // where the rule came from e.g. the name of its config file - see Config.SetOrigin
+	re            *regexp.Regexp
+	start         *regexp.Regexp
+	delimiter     *regexp.Regexp
+	loc           *time.Location
+	layouts       []string // Format and Formats, as Go layouts
+	tsGroup       int      // the submatch of re holding the timestamp
+	hasFields     bool     // true if re has named groups other than the timestamp
+	reorderWindow time.Duration
+	expected      time.Time
+}
+
+// The name of the group in a rule's regex that holds the timestamp
+const TimestampGroup = "ts"
+
+// DecodeConfig reads a TOML config from r and compiles its rules.
+func DecodeConfig(r io.Reader) (*Config, error) {
+	var conf Config
+	if _, err := toml.DecodeReader(r, &conf); err != nil {
+		return nil, fmt.Errorf("error decoding toml: %w", err)
+	}
+	for i, m := range conf.Match {
+		re, err := regexp.Compile(m.Match)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing regex %s: %w", m.Match, err)
+		}
+		conf.Match[i].re = re
+		conf.Match[i].tsGroup = 1
+		if ts := re.SubexpIndex(TimestampGroup); ts != -1 {
+			conf.Match[i].tsGroup = ts
+		}
+		if re.NumSubexp() < conf.Match[i].tsGroup && !m.Disabled {
+			return nil, fmt.Errorf("regex %s has no group for the timestamp", m.Match)
+		}
+		for j, name := range re.SubexpNames() {
+			if name != "" && j != conf.Match[i].tsGroup {
+				conf.Match[i].hasFields = true
+			}
+		}
+		for _, glob := range append(m.Files, m.ExcludeFiles...) {
+			if _, err := filepath.Match(glob, ""); err != nil {
+				return nil, fmt.Errorf("error parsing files pattern %s for regex %s: %w", glob, m.Match, err)
+			}
+		}
+		for _, format := range append([]string{m.Format}, m.Formats...) {
+			if format == "" {
+				continue
+			}
+			if isStrptime(format) {
+				if format, err = strptimeToLayout(format); err != nil {
+					return nil, fmt.Errorf("error parsing format for regex %s: %w", m.Match, err)
+				}
+			}
+			conf.Match[i].layouts = append(conf.Match[i].layouts, format)
+		}
+		if m.Start != "" {
+			if conf.Match[i].start, err = regexp.Compile(m.Start); err != nil {
+				return nil, fmt.Errorf("error parsing record start regex %s: %w", m.Start, err)
+			}
+		}
+		if m.Delimiter != "" {
+			if conf.Match[i].delimiter, err = regexp.Compile(m.Delimiter); err != nil {
+				return nil, fmt.Errorf("error parsing record delimiter regex %s: %w", m.Delimiter, err)
+			}
+		}
+		if m.Timezone != "" {
+			if conf.Match[i].loc, err = time.LoadLocation(m.Timezone); err != nil {
+				return nil, fmt.Errorf("error loading timezone %s for regex %s: %w", m.Timezone, m.Match, err)
+			}
+		}
+		if _, ok := unitDigits[m.Unit]; m.Unit != "" && !ok {
+			return nil, fmt.Errorf("unexpected unit '%s' for regex %s", m.Unit, m.Match)
+		}
+		if m.ReorderWindow != "" {
+			if conf.Match[i].reorderWindow, err = time.ParseDuration(m.ReorderWindow); err != nil {
+				return nil, fmt.Errorf("error parsing reorder window for regex %s: %w", m.Match, err)
+			}
+		}
+		if conf.Match[i].reorderWindow < 0 || m.ReorderLines < 0 {
+			return nil, fmt.Errorf("negative reorder window for regex %s", m.Match)
+		}
+		switch {
+		case m.Since != "" && m.Since != SinceBoot:
+			return nil, fmt.Errorf("unexpected since '%s' for regex %s", m.Since, m.Match)
+		case m.Since != "" && m.Unit == "":
+			return nil, fmt.Errorf("regex %s counts its timestamps since %s, so needs a unit", m.Match, m.Since)
+		}
+		if m.Expected != "" {
+			if conf.Match[i].expected, err = time.Parse(time.RFC3339Nano, m.Expected); err != nil {
+				return nil, fmt.Errorf("error parsing expected time %s for regex %s: %w", m.Expected, m.Match, err)
+			}
+		}
+		switch m.Order {
+		case "", OrderAuto, OrderAscending, OrderDescending:
+		default:
+			return nil, fmt.Errorf("unexpected order '%s' for regex %s", m.Order, m.Match)
+		}
+	}
+	for i, tz := range conf.Timezone {
+		if _, err := filepath.Match(tz.Files, ""); err != nil {
+			return nil, fmt.Errorf("error parsing files pattern %s: %w", tz.Files, err)
+		}
+		loc, err := time.LoadLocation(tz.Zone)
+		if err != nil {
+			return nil, fmt.Errorf("error loading timezone %s for files %s: %w", tz.Zone, tz.Files, err)
+		}
+		conf.Timezone[i].loc = loc
+	}
+	for name, profile := range conf.Profile {
+		for _, key := range []string{ProfileRules, ProfileExcludeRules} {
+			if _, err := profile.names(key); err != nil {
+				return nil, fmt.Errorf("error in profile %s: %w", name, err)
+			}
+		}
+	}
+	conf.offsets = make(map[string]int, len(conf.Abbreviations))
+	for name, offset := range conf.Abbreviations {
+		off, err := parseZoneOffset(offset)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing offset for timezone abbreviation %s: %w", name, err)
+		}
+		conf.offsets[name] = off
+	}
+	return &conf, nil
+}
+
+// SetOrigin records where the rules and timezones in c came from, e.g. the
+// name of the config file they were decoded from.
+func (c *Config) SetOrigin(origin string) {
+	for i := range c.Match {
+		c.Match[i].Origin = origin
+	}
+	for i := range c.Timezone {
+		c.Timezone[i].Origin = origin
+	}
+	c.profileOrigins = make(map[string]string, len(c.Profile))
+	for name := range c.Profile {
+		c.profileOrigins[name] = origin
+	}
+}
+
+// ProfileOrigin returns where the profile called name came from, if known.
+func (c *Config) ProfileOrigin(name string) string {
+	return c.profileOrigins[name]
+}
+
+// names returns the setting key of the profile, a list of rule names.
+func (p Profile) names(key string) ([]string, error) {
+	value, ok := p[key]
+	if !ok {
+		return nil, nil
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s should be a list of rule names", key)
+	}
+	res := make([]string, 0, len(list))
+	for _, v := range list {
+		name, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s should be a list of rule names", key)
+		}
+		res = append(res, name)
+	}
+	return res, nil
+}
+
+// UseProfile keeps only the rules chosen by the profile called name - those
+// listed by its ProfileRules setting, if it has one, other than those listed
+// by its ProfileExcludeRules. It returns an error if there's no such profile,
+// or it names a rule that isn't in c.
+func (c *Config) UseProfile(name string) error {
+	profile, ok := c.Profile[name]
+	if !ok {
+		names := make([]string, 0, len(c.Profile))
+		for name := range c.Profile {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return fmt.Errorf("no profile called %s - the config has no profiles", name)
+		}
+		return fmt.Errorf("no profile called %s - expected one of %s", name, strings.Join(names, ", "))
+	}
+	// Checked by DecodeConfig
+	use, _ := profile.names(ProfileRules)
+	exclude, _ := profile.names(ProfileExcludeRules)
+
+	known := make(map[string]bool)
+	for _, m := range c.Match {
+		if m.Name != "" {
+			known[m.Name] = true
+		}
+	}
+	chosen := make(map[string]bool)
+	for _, rule := range use {
+		chosen[rule] = true
+	}
+	for _, rule := range exclude {
+		chosen[rule] = false
+	}
+	for _, rule := range append(use, exclude...) {
+		if !known[rule] {
+			err := fmt.Errorf("profile %s names rule %s, but there's no rule called that", name, rule)
+			if origin := c.profileOrigins[name]; origin != "" {
+				err = fmt.Errorf("%s: %w", origin, err)
+			}
+			return err
+		}
+	}
+
+	res := c.Match[:0]
+	for _, m := range c.Match {
+		keep, listed := chosen[m.Name]
+		if listed && keep || !listed && len(use) == 0 {
+			res = append(res, m)
+		}
+	}
+	c.Match = res
+	return nil
+}
+
+// Append adds the rules from other after those already in c, so that the
+// rules in c take precedence. Rules and profiles in other with the same name as
+// one in c are dropped.
+func (c *Config) Append(other *Config) {
+	names := make(map[string]bool)
+	for _, m := range c.Match {
+		if m.Name != "" {
+			names[m.Name] = true
+		}
+	}
+	for _, m := range other.Match {
+		if m.Name == "" || !names[m.Name] {
+			c.Match = append(c.Match, m)
+		}
+	}
+	c.Timezone = append(c.Timezone, other.Timezone...)
+	for name, profile := range other.Profile {
+		if _, ok := c.Profile[name]; ok {
+			continue
+		}
+		if c.Profile == nil {
+			c.Profile = make(map[string]Profile)
+		}
+		if c.profileOrigins == nil {
+			c.profileOrigins = make(map[string]string)
+		}
+		c.Profile[name] = profile
+		c.profileOrigins[name] = other.profileOrigins[name]
+	}
+	for name, off := range other.offsets {
+		if _, ok := c.offsets[name]; !ok {
+			if c.offsets == nil {
+				c.offsets = make(map[string]int)
+			}
+			c.offsets[name] = off
+		}
+	}
+}
+
+// rulesFor returns the rules that apply to src, in the order to try them.
+func (c *Config) rulesFor(src *Source) []*Match {
+	res := make([]*Match, 0, len(c.Match))
+	for i := range c.Match {
+		if c.Match[i].appliesTo(src) {
+			res = append(res, &c.Match[i])
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Priority > res[j].Priority
+	})
+	return res
+}
+
+// location returns the timezone that the config sets for src, or nil.
+func (c *Config) location(src *Source) *time.Location {
+	for _, tz := range c.Timezone {
+		if src.MatchesGlob(tz.Files) {
+			return tz.loc
+		}
+	}
+	return nil
+}
+
+// OpenDefaultConfig returns the built-in config as TOML.
+func OpenDefaultConfig() (io.ReadCloser, error) {
+	return openAsset("/logweaver.toml")
+}
+
+// OpenEmptyConfig returns a commented template for a user config as TOML.
+func OpenEmptyConfig() (io.ReadCloser, error) {
+	return openAsset("/empty.toml")
+}
+
+func openAsset(name string) (io.ReadCloser, error) {
+	statikFS, err := fs.New()
+	if err != nil {
+		return nil, err
+	}
+	return statikFS.Open(name)
+}
+
+// appliesTo returns true if the rule can be used for src.
+func (m *Match) appliesTo(src *Source) bool {
+	if m.Disabled {
+		return false
+	}
+	if len(m.Files) > 0 && !src.matchesAnyGlob(m.Files) {
+		return false
+	}
+	return !src.matchesAnyGlob(m.ExcludeFiles)
+}
+
+// parseTimestamp interprets ts, a timestamp extracted from a log line by
+// this rule's regex. If ts doesn't include a timezone, it's taken to be in loc,
+// or UTC if loc is nil. If it names one by abbreviation, without an offset,
+// the offset is looked up in abbrevs. If it doesn't include the year, the year
+// is 0. guessed is true if the timestamp was parsed by dateparse, rather than
+// by the rule's format.
+func (m *Match) parseTimestamp(ts string, loc *time.Location, abbrevs abbreviations) (t time.Time, guessed bool, err error) {
+	if m.Unit != "" {
+		t, err = parseEpoch(ts, m.Unit)
+		return t, false, err
+	}
+	guess := true
+	used := ""
+	for _, layout := range m.layouts {
+		if loc == nil {
+			t, err = time.Parse(layout, ts)
+		} else {
+			t, err = time.ParseInLocation(layout, ts, loc)
+		}
+		if err == nil {
+			guess = false
+			used = layout
+			break
+		}
+	}
+	if guess {
+		t, err = dateparse.ParseIn(ts, loc)
+	}
+	if err == nil {
+		t = abbrevs.resolve(t, ts, used)
+	}
+	return t, guess, err
+}
+
+// timestampSpan returns the start and end of the timestamp in a line, given
+// the submatch indices of the rule's regex for it. ok is false if the regex
+// didn't match, or the timestamp's group didn't take part in the match.
+func (m *Match) timestampSpan(matches []int) (start int, end int, ok bool) {
+	if len(matches) < 2*m.tsGroup+2 || matches[2*m.tsGroup] < 0 {
+		return 0, 0, false
+	}
+	return matches[2*m.tsGroup], matches[2*m.tsGroup+1], true
+}
+
+// fields returns the text of the rule's named groups in line, other than the
+// timestamp, given the submatch indices of the rule's regex for it. It
+// returns nil if there are none.
+func (m *Match) fields(line string, matches []int) map[string]string {
+	if !m.hasFields {
+		return nil
+	}
+	var res map[string]string
+	for i, name := range m.re.SubexpNames() {
+		if name == "" || i == m.tsGroup || matches[2*i] < 0 {
+			continue
+		}
+		if res == nil {
+			res = make(map[string]string)
+		}
+		res[name] = line[matches[2*i]:matches[2*i+1]]
+	}
+	return res
+}
+
+// timestamp extracts and parses the timestamp in line, if this rule matches
+// it. matches holds the submatch indices of the regex.
+func (m *Match) timestamp(line string) (time.Time, []int, bool) {
+	matches := m.re.FindStringSubmatchIndex(line)
+	start, end, ok := m.timestampSpan(matches)
+	if !ok {
+		return time.Time{}, nil, false
+	}
+	tm, _, err := m.parseTimestamp(line[start:end], m.loc, builtinAbbreviations)
+	if err != nil {
+		return time.Time{}, nil, false
+	}
+	return tm, matches, true
+}
+
+// groupsRecords returns true if the rule groups lines into multi-line records.
+func (m *Match) groupsRecords() bool {
+	return m.start != nil || m.delimiter != nil
+}
+
+// recordBounds returns whether line starts a record, and whether it ends one.
+// prevEnded is true if the previous line ended a record.
+func (m *Match) recordBounds(line string, prevEnded bool) (start bool, end bool) {
+	if m.start != nil {
+		start = m.start.MatchString(line)
+	} else {
+		start = prevEnded
+	}
+	end = m.delimiter != nil && m.delimiter.MatchString(line)
+	return start, end
+}
@@ -0,0 +1,278 @@
+package weaver
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourceTimezone(t *testing.T) {
+	conf, err := DecodeConfig(strings.NewReader(`
+[[timezone]]
+files = '*ny*.log'
+zone = 'America/New_York'
+
+[[match]]
+name = 'berlin'
+match = '^(\d{4}-\d\d-\d\d \d\d:\d\d:\d\d) '
+format = '2006-01-02 15:04:05'
+timezone = 'Europe/Berlin'
+files = ['berlin*.log', '*ny*.log']
+
+[[match]]
+name = 'zoned'
+match = '^(\d{4}-\d\d-\d\d \d\d:\d\d:\d\d [-+]\d{4}) '
+format = '2006-01-02 15:04:05 -0700'
+timezone = 'Europe/Berlin'
+` + testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		line     string
+		location *time.Location // as set by --source-timezone
+		want     string
+	}{
+		{"utc.log", "2020-10-05 16:00:00 no zone", nil, "2020-10-05T16:00:00Z"},
+		{"berlin.log", "2020-10-05 16:00:00 from the rule", nil, "2020-10-05T14:00:00Z"},
+		{"ny.log", "2020-10-05 16:00:00 from [[timezone]], ahead of the rule", nil, "2020-10-05T20:00:00Z"},
+		{"berlin2.log", "2020-10-05 16:00:00 from the command line, ahead of the config", tokyo, "2020-10-05T07:00:00Z"},
+		{"zoned.log", "2020-10-05 16:00:00 +0100 with its own zone", nil, "2020-10-05T15:00:00Z"},
+		{"zoned2.log", "2020-10-05 16:00:00 +0100 with its own zone, whatever the command line says", tokyo, "2020-10-05T15:00:00Z"},
+	}
+	for _, test := range tests {
+		src, err := NewSource(test.name, strings.NewReader(test.line+"\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		src.Location = test.location
+		m := NewMerger(conf, []*Source{src}, Options{})
+		rec, err := m.Next()
+		if assert.NoError(t, err, test.name) {
+			assert.Equal(t, test.want, rec.Time.UTC().Format(time.RFC3339), test.name)
+			_, err = m.Next()
+			assert.Equal(t, io.EOF, err, test.name)
+		}
+		m.Close()
+		src.Close()
+	}
+}
+
+func TestRulesFor(t *testing.T) {
+	decode := func(text string) *Config {
+		conf, err := DecodeConfig(strings.NewReader(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return conf
+	}
+	rule := func(name string, extra string) string {
+		return "[[match]]\nname = '" + name + "'\nmatch = '^(\\S+) '\nformat = '2006-01-02T15:04:05'\n" + extra + "\n"
+	}
+
+	// The user's config comes first, then the built-in one
+	var conf Config
+	conf.Append(decode(
+		rule("confd", "files = ['confd.log*']") +
+			rule("not-traces", "exclude_files = ['*.trace']") +
+			rule("iso", "priority = -1") +
+			rule("bracketed", "disabled = true")))
+	conf.Append(decode(
+		rule("iso", "") +
+			rule("bracketed", "") +
+			rule("urgent", "priority = 5\nfiles = ['*/urgent/*']") +
+			rule("plain", "")))
+
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"app.log", []string{"not-traces", "plain", "iso"}},
+		{"confd.log.1", []string{"confd", "not-traces", "plain", "iso"}},
+		{"logs/confd.log", []string{"confd", "not-traces", "plain", "iso"}},
+		{"app.trace", []string{"plain", "iso"}},
+		{"logs/urgent/app.log", []string{"urgent", "not-traces", "plain", "iso"}},
+	}
+	for _, test := range tests {
+		src, err := NewSource(test.name, strings.NewReader(""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, m := range conf.rulesFor(src) {
+			got = append(got, m.Name)
+		}
+		assert.Equal(t, test.want, got, test.name)
+		src.Close()
+	}
+}
+
+func TestFields(t *testing.T) {
+	const rules = `
+[[match]]
+name = 'fields'
+match = '^(?P<host>\S+) (?P<level>[A-Z]+)(?: \[(?P<pid>\d+)\])? (?P<ts>\d{4}-\d\d-\d\d \d\d:\d\d:\d\d) '
+format = '2006-01-02 15:04:05'
+files = ['fields.log']
+` + testRules
+
+	conf, err := DecodeConfig(strings.NewReader(rules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var srcs Sources
+	for _, log := range []struct{ name, text string }{
+		{"fields.log", "web1 INFO [42] 2020-10-05 16:00:00 started\n" +
+			"  a detail\n" +
+			"web2 WARN 2020-10-05 16:00:02 no pid\n"},
+		{"plain.log", "2020-10-05 16:00:01 no fields\n"},
+	} {
+		src, err := NewSource(log.name, strings.NewReader(log.text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		srcs = append(srcs, src)
+	}
+	defer srcs.Close()
+
+	m := NewMerger(conf, srcs, Options{})
+	defer m.Close()
+	want := []struct {
+		text   string
+		tm     time.Time
+		fields map[string]string
+	}{
+		{"web1 INFO [42] 2020-10-05 16:00:00 started", time.Date(2020, time.October, 5, 16, 0, 0, 0, time.UTC), map[string]string{"host": "web1", "level": "INFO", "pid": "42"}},
+		{"  a detail", time.Date(2020, time.October, 5, 16, 0, 0, 0, time.UTC), nil},
+		{"2020-10-05 16:00:01 no fields", time.Date(2020, time.October, 5, 16, 0, 1, 0, time.UTC), nil},
+		{"web2 WARN 2020-10-05 16:00:02 no pid", time.Date(2020, time.October, 5, 16, 0, 2, 0, time.UTC), map[string]string{"host": "web2", "level": "WARN"}},
+	}
+	for _, w := range want {
+		rec, err := m.Next()
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, w.text, rec.Text)
+		assert.True(t, w.tm.Equal(rec.Time), "%s: time %v", w.text, rec.Time)
+		assert.Equal(t, w.fields, rec.Fields, w.text)
+	}
+	_, err = m.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestTimestampGroupErrors(t *testing.T) {
+	for _, match := range []string{`^\S+ `, `^(?:\S+) `} {
+		_, err := DecodeConfig(strings.NewReader("[[match]]\nmatch = '" + match + "'\nformat = '2006'\n"))
+		assert.Error(t, err, match)
+	}
+	// The timestamp can be any named group, or otherwise the first
+	for _, match := range []string{`^(\S+) (\S+)`, `^(?P<level>\S+) (?P<ts>\S+)`} {
+		_, err := DecodeConfig(strings.NewReader("[[match]]\nmatch = '" + match + "'\nformat = '2006'\n"))
+		assert.NoError(t, err, match)
+	}
+}
+
+func TestUseProfile(t *testing.T) {
+	rules := ""
+	for _, name := range []string{"iso", "bracketed", "syslog"} {
+		rules += "[[match]]\nname = '" + name + "'\nmatch = '^(\\S+) '\nformat = '2006'\n"
+	}
+	user := `
+[profile.only]
+rules = ['syslog', 'iso']
+
+[profile.except]
+exclude_rules = ['bracketed']
+
+[profile.both]
+rules = ['iso', 'bracketed']
+exclude_rules = ['bracketed']
+
+[profile.settings]
+separator = true
+
+[profile.unknown]
+rules = ['nosuch']
+`
+	tests := []struct {
+		profile string
+		want    []string
+		wantErr string
+	}{
+		{profile: "only", want: []string{"iso", "syslog"}},
+		{profile: "except", want: []string{"iso", "syslog"}},
+		{profile: "both", want: []string{"iso"}},
+		{profile: "settings", want: []string{"iso", "bracketed", "syslog"}},
+		{profile: "unknown", wantErr: "user.toml: profile unknown names rule nosuch, but there's no rule called that"},
+		{profile: "nosuch", wantErr: "no profile called nosuch - expected one of both, except, only, settings, unknown"},
+	}
+	for _, test := range tests {
+		userConf, err := DecodeConfig(strings.NewReader(user))
+		if err != nil {
+			t.Fatal(err)
+		}
+		userConf.SetOrigin("user.toml")
+		builtin, err := DecodeConfig(strings.NewReader(rules))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var conf Config
+		conf.Append(userConf)
+		conf.Append(builtin)
+
+		err = conf.UseProfile(test.profile)
+		if test.wantErr != "" {
+			assert.EqualError(t, err, test.wantErr, test.profile)
+			continue
+		}
+		if !assert.NoError(t, err, test.profile) {
+			continue
+		}
+		var got []string
+		for _, m := range conf.Match {
+			got = append(got, m.Name)
+		}
+		assert.Equal(t, test.want, got, test.profile)
+	}
+
+	var conf Config
+	assert.EqualError(t, conf.UseProfile("any"), "no profile called any - the config has no profiles")
+}
+
+func TestProfileErrors(t *testing.T) {
+	for _, profile := range []string{"rules = 'iso'", "exclude_rules = [1, 2]"} {
+		_, err := DecodeConfig(strings.NewReader("[profile.bad]\n" + profile + "\n"))
+		assert.Error(t, err, profile)
+	}
+}
+
+func TestAppendProfiles(t *testing.T) {
+	first, err := DecodeConfig(strings.NewReader("[profile.a]\nseparator = true\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.SetOrigin("first.toml")
+	second, err := DecodeConfig(strings.NewReader("[profile.a]\nalt-style = true\n[profile.b]\nalt-style = true\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	second.SetOrigin("second.toml")
+
+	// The earlier config's profile takes precedence
+	var conf Config
+	conf.Append(first)
+	conf.Append(second)
+	assert.Equal(t, Profile{"separator": true}, conf.Profile["a"])
+	assert.Equal(t, Profile{"alt-style": true}, conf.Profile["b"])
+	assert.Equal(t, "first.toml", conf.ProfileOrigin("a"))
+	assert.Equal(t, "second.toml", conf.ProfileOrigin("b"))
+}
@@ -0,0 +1,200 @@
+package weaver
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WriteTOML writes c as a TOML config, with a comment in each rule, timezone
+// and profile saying where it came from, if known. Settings that are empty are
+// left out.
+func (c *Config) WriteTOML(w io.Writer) error {
+	var b strings.Builder
+	for i, m := range c.Match {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("[[match]]\n")
+		if m.Origin != "" {
+			fmt.Fprintf(&b, "# from %s\n", m.Origin)
+		}
+		writeTOMLString(&b, "name", m.Name)
+		writeTOMLString(&b, "example", m.Example)
+		writeTOMLString(&b, "expected", m.Expected)
+		writeTOMLString(&b, "match", m.Match)
+		writeTOMLString(&b, "format", m.Format)
+		writeTOMLStrings(&b, "formats", m.Formats)
+		writeTOMLStrings(&b, "files", m.Files)
+		writeTOMLStrings(&b, "exclude_files", m.ExcludeFiles)
+		if m.Priority != 0 {
+			fmt.Fprintf(&b, "priority = %d\n", m.Priority)
+		}
+		if m.Disabled {
+			b.WriteString("disabled = true\n")
+		}
+		writeTOMLString(&b, "order", m.Order)
+		writeTOMLString(&b, "start", m.Start)
+		writeTOMLString(&b, "delimiter", m.Delimiter)
+		writeTOMLString(&b, "timezone", m.Timezone)
+		writeTOMLString(&b, "unit", m.Unit)
+		writeTOMLString(&b, "since", m.Since)
+		writeTOMLString(&b, "reorder_window", m.ReorderWindow)
+		if m.ReorderLines != 0 {
+			fmt.Fprintf(&b, "reorder_lines = %d\n", m.ReorderLines)
+		}
+	}
+
+	for _, tz := range c.Timezone {
+		b.WriteString("\n[[timezone]]\n")
+		if tz.Origin != "" {
+			fmt.Fprintf(&b, "# from %s\n", tz.Origin)
+		}
+		writeTOMLString(&b, "files", tz.Files)
+		writeTOMLString(&b, "zone", tz.Zone)
+	}
+
+	if len(c.offsets) > 0 {
+		names := make([]string, 0, len(c.offsets))
+		for name := range c.offsets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		b.WriteString("\n[abbreviations]\n")
+		for _, name := range names {
+			off := c.offsets[name]
+			sign := '+'
+			if off < 0 {
+				sign, off = '-', -off
+			}
+			fmt.Fprintf(&b, "%s = '%c%02d:%02d'\n", name, sign, off/3600, off/60%60)
+		}
+	}
+
+	profiles := make([]string, 0, len(c.Profile))
+	for name := range c.Profile {
+		profiles = append(profiles, name)
+	}
+	sort.Strings(profiles)
+	for _, name := range profiles {
+		fmt.Fprintf(&b, "\n[profile.%s]\n", tomlKey(name))
+		if origin := c.profileOrigins[name]; origin != "" {
+			fmt.Fprintf(&b, "# from %s\n", origin)
+		}
+		profile := c.Profile[name]
+		keys := make([]string, 0, len(profile))
+		for key := range profile {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value, err := tomlValue(profile[key])
+			if err != nil {
+				return fmt.Errorf("error writing %s of profile %s: %w", key, name, err)
+			}
+			fmt.Fprintf(&b, "%s = %s\n", tomlKey(key), value)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// tomlKey quotes key as a TOML key, unless it can be written bare.
+func tomlKey(key string) string {
+	bare := key != "" && strings.IndexFunc(key, func(r rune) bool {
+		return !(r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' || r == '-')
+	}) == -1
+	if bare {
+		return key
+	}
+	return tomlString(key)
+}
+
+// tomlValue writes value, as decoded from TOML, as TOML.
+func tomlValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return tomlString(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		f := strconv.FormatFloat(v, 'g', -1, 64)
+		if !strings.ContainsAny(f, ".eIN") {
+			f += ".0"
+		}
+		return f, nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, elem := range v {
+			s, err := tomlValue(elem)
+			if err != nil {
+				return "", err
+			}
+			values = append(values, s)
+		}
+		return "[" + strings.Join(values, ", ") + "]", nil
+	default:
+		return "", fmt.Errorf("unexpected value %v", value)
+	}
+}
+
+// writeTOMLString writes key = value, unless value is empty.
+func writeTOMLString(b *strings.Builder, key string, value string) {
+	if value != "" {
+		fmt.Fprintf(b, "%s = %s\n", key, tomlString(value))
+	}
+}
+
+// writeTOMLStrings writes key = [values...], unless values is empty.
+func writeTOMLStrings(b *strings.Builder, key string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, tomlString(value))
+	}
+	fmt.Fprintf(b, "%s = [%s]\n", key, strings.Join(quoted, ", "))
+}
+
+// tomlString quotes s as a TOML string - a literal string, as used for regexes
+// in the built-in config, unless s contains a quote or control character.
+func tomlString(s string) string {
+	literal := strings.IndexFunc(s, func(r rune) bool {
+		return r == '\'' || r < 0x20 || r == 0x7f
+	}) == -1
+	if literal {
+		return "'" + s + "'"
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,99 @@
+package weaver
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTOMLString(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{``, `''`},
+		{`^(\S+ \S+) `, `'^(\S+ \S+) '`},
+		{`say "hi"`, `'say "hi"'`},
+		{`it's`, `"it's"`},
+		{`it's \d`, `"it's \\d"`},
+		{"tab\there", `"tab\there"`},
+		{"line\nbreak\r", `"line\nbreak\r"`},
+		{"bell\x07", `"bell\u0007"`},
+	}
+	for _, test := range tests {
+		got := tomlString(test.s)
+		assert.Equal(t, test.want, got, test.s)
+
+		// It must read back as the same string
+		var decoded struct{ S string }
+		if _, err := toml.Decode("s = "+got, &decoded); assert.NoError(t, err, got) {
+			assert.Equal(t, test.s, decoded.S, got)
+		}
+	}
+}
+
+func TestTOMLValue(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  string
+	}{
+		{"Europe/Berlin", `'Europe/Berlin'`},
+		{true, `true`},
+		{false, `false`},
+		{int64(-42), `-42`},
+		{float64(1.5), `1.5`},
+		{float64(2), `2.0`},
+		{float64(1e21), `1e+21`},
+		{time.Date(2020, time.October, 5, 14, 0, 0, 0, time.UTC), `2020-10-05T14:00:00Z`},
+		{[]interface{}{"10s,a.log", "2m,dmesg*"}, `['10s,a.log', '2m,dmesg*']`},
+		{[]interface{}{}, `[]`},
+	}
+	for _, test := range tests {
+		got, err := tomlValue(test.value)
+		if assert.NoError(t, err, "%v", test.value) {
+			assert.Equal(t, test.want, got, "%v", test.value)
+		}
+	}
+
+	_, err := tomlValue(map[string]interface{}{"a": 1})
+	assert.Error(t, err)
+	_, err = tomlValue([]interface{}{"ok", 3})
+	assert.Error(t, err)
+}
+
+func TestWriteTOMLRoundTrip(t *testing.T) {
+	conf, err := DecodeConfig(strings.NewReader(`
+[[match]]
+name = 'app'
+match = '^(?P<ts>\S+ \S+) (?P<level>[A-Z]+) '
+formats = ['%Y-%m-%d %H:%M:%S.%f', "2006-01-02 15:04:05"]
+files = ["app's.log*"]
+priority = 3
+
+[profile.quiet]
+separator = true
+offset = ['10s,a.log']
+`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	var b strings.Builder
+	if !assert.NoError(t, conf.WriteTOML(&b)) {
+		return
+	}
+	again, err := DecodeConfig(strings.NewReader(b.String()))
+	if !assert.NoError(t, err, b.String()) {
+		return
+	}
+	if assert.Len(t, again.Match, 1) {
+		assert.Equal(t, conf.Match[0].Name, again.Match[0].Name)
+		assert.Equal(t, conf.Match[0].Match, again.Match[0].Match)
+		assert.Equal(t, conf.Match[0].Formats, again.Match[0].Formats)
+		assert.Equal(t, conf.Match[0].Files, again.Match[0].Files)
+		assert.Equal(t, conf.Match[0].Priority, again.Match[0].Priority)
+	}
+	assert.Equal(t, conf.Profile, again.Profile)
+}
@@ -0,0 +1,72 @@
+package weaver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Units for Match.Unit, for timestamps given as a count since the Unix epoch
+const (
+	UnitSeconds      = "s"
+	UnitMilliseconds = "ms"
+	UnitMicroseconds = "us"
+	UnitNanoseconds  = "ns"
+)
+
+// unitDigits is the number of decimal places of a second in each unit.
+var unitDigits = map[string]int{
+	UnitSeconds:      0,
+	UnitMilliseconds: 3,
+	UnitMicroseconds: 6,
+	UnitNanoseconds:  9,
+}
+
+// parseEpoch interprets ts as a count of unit since the Unix epoch, e.g.
+// 1600000000.123 seconds or 1516299661909 milliseconds. The count is handled
+// as decimal digits rather than a float, so no precision is lost.
+func parseEpoch(ts string, unit string) (time.Time, error) {
+	digits, ok := unitDigits[unit]
+	if !ok {
+		return time.Time{}, fmt.Errorf("unexpected unit '%s'", unit)
+	}
+	num := ts
+	neg := strings.HasPrefix(num, "-")
+	if neg {
+		num = num[1:]
+	}
+	whole, frac := num, ""
+	if i := strings.IndexByte(num, '.'); i != -1 {
+		whole, frac = num[:i], num[i+1:]
+	}
+	if whole == "" && frac == "" {
+		return time.Time{}, fmt.Errorf("could not parse '%s' as a count of %s", ts, unit)
+	}
+
+	// Shift the decimal point from unit to seconds, then split off nanoseconds
+	whole += frac
+	point := len(whole) - len(frac) - digits
+	for point < 1 {
+		whole = "0" + whole
+		point++
+	}
+	secs, nanos := whole[:point], whole[point:]
+	if len(nanos) > 9 {
+		nanos = nanos[:9]
+	}
+	nanos += strings.Repeat("0", 9-len(nanos))
+
+	sec, err := strconv.ParseInt(secs, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not parse '%s' as a count of %s: %w", ts, unit, err)
+	}
+	nsec, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not parse '%s' as a count of %s: %w", ts, unit, err)
+	}
+	if neg {
+		sec, nsec = -sec, -nsec
+	}
+	return time.Unix(sec, nsec).UTC(), nil
+}
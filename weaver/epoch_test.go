@@ -0,0 +1,52 @@
+package weaver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEpoch(t *testing.T) {
+	tests := []struct {
+		ts   string
+		unit string
+		want time.Time
+	}{
+		{"1600000000", UnitSeconds, time.Unix(1600000000, 0)},
+		{"1600000000.123", UnitSeconds, time.Unix(1600000000, 123000000)},
+		{"1600000000.123456789123", UnitSeconds, time.Unix(1600000000, 123456789)},
+		{"1516299661909", UnitMilliseconds, time.Unix(1516299661, 909000000)},
+		{"1516299661909.5", UnitMilliseconds, time.Unix(1516299661, 909500000)},
+		{"1516299661909123", UnitMicroseconds, time.Unix(1516299661, 909123000)},
+		{"1516299661909123456", UnitNanoseconds, time.Unix(1516299661, 909123456)},
+		{"5", UnitMilliseconds, time.Unix(0, 5000000)},
+		{".5", UnitSeconds, time.Unix(0, 500000000)},
+		{"0", UnitSeconds, time.Unix(0, 0)},
+		{"-1.5", UnitSeconds, time.Unix(-1, -500000000)},
+	}
+	for _, test := range tests {
+		got, err := parseEpoch(test.ts, test.unit)
+		if assert.NoError(t, err, "%s %s", test.ts, test.unit) {
+			assert.True(t, test.want.Equal(got), "%s %s: got %v, want %v", test.ts, test.unit, got, test.want)
+			assert.Equal(t, time.UTC, got.Location())
+		}
+	}
+}
+
+func TestParseEpochErrors(t *testing.T) {
+	tests := []struct {
+		ts   string
+		unit string
+	}{
+		{"", UnitSeconds},
+		{".", UnitSeconds},
+		{"-", UnitSeconds},
+		{"12a", UnitSeconds},
+		{"1600000000", "min"},
+	}
+	for _, test := range tests {
+		_, err := parseEpoch(test.ts, test.unit)
+		assert.Error(t, err, "%q %s", test.ts, test.unit)
+	}
+}
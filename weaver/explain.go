@@ -0,0 +1,155 @@
+package weaver
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Explanation describes how the lines of a source were parsed, to help
+// diagnose unexpected output, and to help write rules.
+type Explanation struct {
+	Source        *Source
+	Rule          *Match    // the rule chosen for the source, or nil if none yielded a timestamp
+	RuleIndex     int       // the position of Rule in the config, or -1
+	LaterRules    []*Match  // rules switched to later, e.g. because the log's format changed
+	Descending    bool      // true if the source was read back to front, its timestamps running newest first
+	Example       string    // the first line a timestamp was taken from
+	ExampleMatch  [2]int    // the start and end of the timestamp in Example
+	ExampleTime   time.Time // the timestamp of Example, as parsed
+	Lines         int       // the number of lines read
+	Parsed        int       // lines with a timestamp of their own
+	Guessed       int       // lines whose timestamp was parsed by dateparse, rather than the rule's format
+	Skipped       int       // lines skipped at the start of the source, before the first line with a timestamp
+	Continuations int       // lines taken to continue the line before, and given its timestamp
+	OutOfOrder    int       // lines with a timestamp earlier than the line before, treated as continuations
+	Reordered     int       // lines with a timestamp earlier than the line before, put back in order by the reorder buffer
+	First         time.Time // the earliest timestamp
+	Last          time.Time // the latest timestamp
+}
+
+// noteParsed records that a timestamp tm was taken from line, between start
+// and end.
+func (p *parser) noteParsed(line string, start, end int, tm time.Time, guessed bool) {
+	p.stats.Parsed++
+	if guessed {
+		p.stats.Guessed++
+	}
+	if p.stats.Example == "" {
+		p.stats.Example = line
+		p.stats.ExampleMatch = [2]int{start, end}
+		p.stats.ExampleTime = tm
+	}
+}
+
+// noteLine records that pl is to be merged.
+func (p *parser) noteLine(pl *parsedLine) {
+	p.stats.Lines++
+	if pl.continuation {
+		p.stats.Continuations++
+		return
+	}
+	if p.stats.First.IsZero() || pl.tm.Before(p.stats.First) {
+		p.stats.First = pl.tm
+	}
+	if pl.tm.After(p.stats.Last) {
+		p.stats.Last = pl.tm
+	}
+}
+
+// Explain reads every source to the end, and returns how each was parsed, in
+// the order of Sources. It can't be used when following.
+func (m *Merger) Explain() ([]*Explanation, error) {
+	if m.opts.Follow {
+		return nil, fmt.Errorf("sources can't be explained while following")
+	}
+	for {
+		if _, err := m.Next(); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	res := make([]*Explanation, 0, len(m.states))
+	for _, s := range m.states {
+		// The parser has finished, so its stats can be read
+		e := s.parser.stats
+		e.Source = s.src
+		e.RuleIndex = -1
+		for i := range m.conf.Match {
+			if &m.conf.Match[i] == e.Rule {
+				e.RuleIndex = i
+			}
+		}
+		res = append(res, &e)
+	}
+	return res, nil
+}
+
+// Write describes e in a human-readable form.
+func (e *Explanation) Write(w io.Writer) error {
+	const timeFormat = "2006-01-02 15:04:05.000000000 MST"
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", e.Source.Name)
+	if e.Rule == nil {
+		fmt.Fprintf(&b, "  No rule yielded a timestamp from any of its %d lines\n\n", e.Lines)
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+	rule := fmt.Sprintf("#%d", e.RuleIndex+1)
+	if e.Rule.Name != "" {
+		rule += " " + e.Rule.Name
+	}
+	fmt.Fprintf(&b, "  Rule:          %s\n", rule)
+	fmt.Fprintf(&b, "  Match:         %s\n", e.Rule.Match)
+	switch {
+	case e.Rule.Since == SinceBoot && e.Source.Booted.IsZero():
+		fmt.Fprintf(&b, "  Unit:          %s since boot, at an unknown time\n", e.Rule.Unit)
+	case e.Rule.Since == SinceBoot:
+		fmt.Fprintf(&b, "  Unit:          %s since boot, at %s\n", e.Rule.Unit, e.Source.Booted.UTC().Format(timeFormat))
+	case e.Rule.Unit != "":
+		fmt.Fprintf(&b, "  Unit:          %s since the Unix epoch\n", e.Rule.Unit)
+	case len(e.Rule.layouts) > 0:
+		for _, format := range append([]string{e.Rule.Format}, e.Rule.Formats...) {
+			if format != "" {
+				fmt.Fprintf(&b, "  Format:        %s\n", format)
+			}
+		}
+	default:
+		fmt.Fprintf(&b, "  Format:        none - guessed by dateparse\n")
+	}
+	for _, later := range e.LaterRules {
+		fmt.Fprintf(&b, "  Changed to:    %s\n", later.Match)
+	}
+	if e.Descending {
+		fmt.Fprintf(&b, "  Order:         newest first - read back to front\n")
+	}
+	if e.Example != "" {
+		fmt.Fprintf(&b, "  Example:       %s\n", e.Example)
+		fmt.Fprintf(&b, "                 %s%s\n",
+			strings.Repeat(" ", len([]rune(e.Example[:e.ExampleMatch[0]]))),
+			strings.Repeat("^", len([]rune(e.Example[e.ExampleMatch[0]:e.ExampleMatch[1]]))),
+		)
+		fmt.Fprintf(&b, "  Parsed as:     %s\n", e.ExampleTime.UTC().Format(timeFormat))
+	}
+	fmt.Fprintf(&b, "  Lines:         %d\n", e.Lines)
+	fmt.Fprintf(&b, "  With time:     %d", e.Parsed)
+	if e.Guessed > 0 {
+		fmt.Fprintf(&b, " (%d guessed by dateparse, not the format)", e.Guessed)
+	}
+	fmt.Fprintf(&b, "\n")
+	fmt.Fprintf(&b, "  Skipped:       %d at the start\n", e.Skipped)
+	fmt.Fprintf(&b, "  Continuations: %d\n", e.Continuations)
+	fmt.Fprintf(&b, "  Out of order:  %d\n", e.OutOfOrder)
+	if e.Reordered > 0 {
+		fmt.Fprintf(&b, "  Reordered:     %d\n", e.Reordered)
+	}
+	if !e.First.IsZero() {
+		fmt.Fprintf(&b, "  First:         %s\n", e.First.UTC().Format(timeFormat))
+		fmt.Fprintf(&b, "  Last:          %s\n", e.Last.UTC().Format(timeFormat))
+	}
+	fmt.Fprintf(&b, "\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,136 @@
+package weaver
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// explain returns the explanation of each of the logs with the given texts,
+// parsed with rules.
+func explain(t *testing.T, rules string, names []string, texts []string, opts Options) []*Explanation {
+	conf, err := DecodeConfig(strings.NewReader(rules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcs := make(Sources, 0, len(texts))
+	for i, text := range texts {
+		src, err := NewSource(names[i], strings.NewReader(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		srcs = append(srcs, src)
+	}
+	defer srcs.Close()
+
+	m := NewMerger(conf, srcs, opts)
+	defer m.Close()
+	res, err := m.Explain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestExplain(t *testing.T) {
+	at := func(secs int) time.Time {
+		return time.Date(2020, time.October, 5, 16, 0, secs, 0, time.UTC)
+	}
+	res := explain(t, `
+[[match]]
+name = 'other'
+match = '^<(.*)> '
+format = '2006'
+`+testRules, []string{"app.log", "empty.log", "unknown.log"}, []string{
+		"banner\n" +
+			"another banner\n" +
+			"2020-10-05 16:00:01 started\n" +
+			"  a detail\n" +
+			"2020-10-05 16:00:03 running\n" +
+			"2020-10-05 16:00:02 out of order\n" +
+			"2020-10-05 16:00:04 stopped\n",
+		"",
+		"no times here\n",
+	}, Options{})
+	if !assert.Len(t, res, 3) {
+		return
+	}
+
+	app := res[0]
+	assert.Equal(t, "app.log", app.Source.Name)
+	if assert.NotNil(t, app.Rule) {
+		assert.Equal(t, "iso", app.Rule.Name)
+	}
+	assert.Equal(t, 1, app.RuleIndex)
+	assert.Equal(t, "2020-10-05 16:00:01 started", app.Example)
+	assert.Equal(t, [2]int{0, 19}, app.ExampleMatch)
+	assert.True(t, at(1).Equal(app.ExampleTime), app.ExampleTime)
+	assert.Equal(t, 7, app.Lines)
+	assert.Equal(t, 4, app.Parsed)
+	assert.Equal(t, 0, app.Guessed)
+	assert.Equal(t, 2, app.Skipped)
+	assert.Equal(t, 2, app.Continuations)
+	assert.Equal(t, 1, app.OutOfOrder)
+	assert.True(t, at(1).Equal(app.First), app.First)
+	assert.True(t, at(4).Equal(app.Last), app.Last)
+
+	for _, e := range res[1:] {
+		assert.Nil(t, e.Rule, e.Source.Name)
+		assert.Equal(t, -1, e.RuleIndex, e.Source.Name)
+	}
+
+	var b strings.Builder
+	if assert.NoError(t, app.Write(&b)) {
+		assert.Equal(t, `app.log
+  Rule:          #2 iso
+  Match:         ^(\d{4}-\d\d-\d\d \d\d:\d\d:\d\d) 
+  Format:        2006-01-02 15:04:05
+  Example:       2020-10-05 16:00:01 started
+                 ^^^^^^^^^^^^^^^^^^^
+  Parsed as:     2020-10-05 16:00:01.000000000 UTC
+  Lines:         7
+  With time:     4
+  Skipped:       2 at the start
+  Continuations: 2
+  Out of order:  1
+  First:         2020-10-05 16:00:01.000000000 UTC
+  Last:          2020-10-05 16:00:04.000000000 UTC
+
+`, b.String())
+	}
+
+	b.Reset()
+	if assert.NoError(t, res[2].Write(&b)) {
+		assert.Equal(t, "unknown.log\n  No rule yielded a timestamp from any of its 1 lines\n\n", b.String())
+	}
+}
+
+func TestExplainGuessed(t *testing.T) {
+	res := explain(t, `
+[[match]]
+match = '^\[(.*?)\] '
+`, []string{"app.log"}, []string{
+		"[2020-10-05 16:00:01] started\n" +
+			"[Mon Oct 5 16:00:02 UTC 2020] running\n",
+	}, Options{})
+	if assert.Len(t, res, 1) {
+		assert.Equal(t, 2, res[0].Parsed)
+		assert.Equal(t, 2, res[0].Guessed)
+
+		var b strings.Builder
+		res[0].Write(&b)
+		assert.Contains(t, b.String(), "  Format:        none - guessed by dateparse\n")
+		assert.Contains(t, b.String(), "  With time:     2 (2 guessed by dateparse, not the format)\n")
+	}
+}
+
+func TestExplainFollowing(t *testing.T) {
+	conf, err := DecodeConfig(strings.NewReader(testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewMerger(conf, nil, Options{Follow: true}).Explain()
+	assert.Error(t, err)
+}
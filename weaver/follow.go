@@ -0,0 +1,251 @@
+package weaver
+
+import (
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+const defaultPollInterval = 250 * time.Millisecond
+
+// follower is implemented by readers that wait for more data instead of
+// returning io.EOF. Before waiting, they call idle, which can stop the reader
+// by returning false.
+type follower interface {
+	setIdle(idle func() bool)
+}
+
+// followReader reads a growing file, like tail -F. Instead of returning io.EOF
+// at the end of the file, it waits for more data. If the file is rotated, the
+// new file at the same path is read from the start; if the file is truncated,
+// it is read again from the start.
+type followReader struct {
+	name   string
+	poll   time.Duration
+	closed chan struct{}
+
+	mu   sync.Mutex
+	file *os.File
+	seen []os.FileInfo // every file followed so far, including those rotated away
+	pos  int64         // bytes read so far from file
+	idle func() bool   // if not nil, called before waiting for more data; returning false stops the reader
+}
+
+var _ io.ReadCloser = (*followReader)(nil)
+var _ follower = (*followReader)(nil)
+
+func newFollowReader(name string, file *os.File, fi os.FileInfo, poll time.Duration) *followReader {
+	if poll == 0 {
+		poll = defaultPollInterval
+	}
+	return &followReader{
+		name:   name,
+		poll:   poll,
+		closed: make(chan struct{}),
+		file:   file,
+		seen:   []os.FileInfo{fi},
+	}
+}
+
+// hasFollowed returns true if fi is, or was, the file being followed.
+func (f *followReader) hasFollowed(fi os.FileInfo) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, seen := range f.seen {
+		if os.SameFile(seen, fi) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *followReader) setIdle(idle func() bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.idle = idle
+}
+
+// Read blocks until at least one byte is available, or the reader is closed.
+func (f *followReader) Read(p []byte) (int, error) {
+	for {
+		n, err := f.read(p)
+		if n > 0 || (err != nil && err != io.EOF) {
+			return n, err
+		}
+
+		f.mu.Lock()
+		idle := f.idle
+		f.mu.Unlock()
+		if idle != nil && !idle() {
+			return 0, io.EOF
+		}
+
+		select {
+		case <-f.closed:
+			return 0, io.EOF
+		case <-time.After(f.poll):
+		}
+	}
+}
+
+// read reads from the current file, switching to a new file if it has been
+// rotated or rewinding if it has been truncated. It returns io.EOF if there
+// is nothing new to read yet.
+func (f *followReader) read(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	select {
+	case <-f.closed:
+		return 0, os.ErrClosed
+	default:
+	}
+
+	n, err := f.file.Read(p)
+	f.pos += int64(n)
+	if n > 0 || err != io.EOF {
+		return n, err
+	}
+
+	cur, err := f.file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	fi, err := os.Stat(f.name)
+	switch {
+	case err != nil:
+		// Rotated away, and not yet replaced - keep waiting.
+	case !os.SameFile(fi, cur):
+		// Rotated. Pick up anything written to the old file since we reached its end,
+		// then move to the new one.
+		n, err = f.file.Read(p)
+		f.pos += int64(n)
+		if n > 0 {
+			return n, nil
+		}
+		file, err := os.Open(f.name)
+		if err != nil {
+			break
+		}
+		f.file.Close()
+		f.file = file
+		f.seen = append(f.seen, fi)
+		f.pos = 0
+		n, err = f.file.Read(p)
+		f.pos += int64(n)
+		return n, err
+	case cur.Size() < f.pos:
+		// Truncated, e.g. by logrotate's copytruncate.
+		if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+			return 0, err
+		}
+		f.pos = 0
+		n, err = f.file.Read(p)
+		f.pos += int64(n)
+		return n, err
+	}
+	return 0, io.EOF
+}
+
+// Close stops the reader, and closes the file being followed.
+func (f *followReader) Close() error {
+	select {
+	case <-f.closed:
+		return nil
+	default:
+		close(f.closed)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
+
+// followPipe reads a pipe or other stream that may pause between writes. The
+// stream is read by a separate goroutine, so that followPipe can tell when it
+// is about to wait for more data.
+type followPipe struct {
+	chunks chan []byte
+	err    error // set before chunks is closed
+	cur    []byte
+	closed chan struct{}
+
+	mu   sync.Mutex
+	idle func() bool // if not nil, called before waiting for more data; returning false stops the reader
+}
+
+var _ io.ReadCloser = (*followPipe)(nil)
+var _ follower = (*followPipe)(nil)
+
+func newFollowPipe(r io.Reader) *followPipe {
+	f := &followPipe{
+		chunks: make(chan []byte, 1),
+		closed: make(chan struct{}),
+	}
+	go func() {
+		defer close(f.chunks)
+		for {
+			buf := make([]byte, 65536)
+			n, err := r.Read(buf)
+			if n > 0 {
+				select {
+				case f.chunks <- buf[:n]:
+				case <-f.closed:
+					return
+				}
+			}
+			if err != nil {
+				f.err = err
+				return
+			}
+		}
+	}()
+	return f
+}
+
+func (f *followPipe) setIdle(idle func() bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.idle = idle
+}
+
+// Read returns data already read from the stream if there is any, and
+// otherwise waits for more.
+func (f *followPipe) Read(p []byte) (int, error) {
+	if len(f.cur) == 0 {
+		var ok bool
+		select {
+		case f.cur, ok = <-f.chunks:
+		default:
+			f.mu.Lock()
+			idle := f.idle
+			f.mu.Unlock()
+			if idle != nil && !idle() {
+				return 0, io.EOF
+			}
+			select {
+			case f.cur, ok = <-f.chunks:
+			case <-f.closed:
+				return 0, io.EOF
+			}
+		}
+		if !ok {
+			return 0, f.err
+		}
+	}
+	n := copy(p, f.cur)
+	f.cur = f.cur[n:]
+	return n, nil
+}
+
+// Close stops the reader, and the goroutine reading the stream - straight away
+// if it is waiting to hand over data, or otherwise once its read of the stream
+// returns. The stream itself is not closed.
+func (f *followPipe) Close() error {
+	select {
+	case <-f.closed:
+	default:
+		close(f.closed)
+	}
+	return nil
+}
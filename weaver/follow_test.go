@@ -0,0 +1,188 @@
+package weaver
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// available returns what r has to read now, without waiting for more.
+func available(t *testing.T, r *followReader) string {
+	r.setIdle(func() bool { return false })
+	defer r.setIdle(nil)
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func appendFile(t *testing.T, name string, text string) {
+	file, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.WriteString(text); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFollowReader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logweaver-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "app.log")
+	appendFile(t, name, "one\n")
+	file, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fi, err := file.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newFollowReader(name, file, fi, time.Millisecond)
+	defer r.Close()
+
+	assert.Equal(t, "one\n", available(t, r))
+	assert.Equal(t, "", available(t, r))
+
+	// Growing
+	appendFile(t, name, "two\n")
+	assert.Equal(t, "two\n", available(t, r))
+
+	// Truncated, as by logrotate's copytruncate
+	if err := ioutil.WriteFile(name, []byte("three\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "three\n", available(t, r))
+
+	// Rotated away, with a last line written to it, and not yet replaced
+	appendFile(t, name, "four\n")
+	if err := os.Rename(name, name+".1"); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "four\n", available(t, r))
+	appendFile(t, name+".1", "five\n")
+
+	// Replaced
+	appendFile(t, name, "six\n")
+	assert.Equal(t, "five\nsix\n", available(t, r))
+
+	for _, seen := range []string{name, name + ".1"} {
+		fi, err := os.Stat(seen)
+		if assert.NoError(t, err) {
+			assert.True(t, r.hasFollowed(fi), seen)
+		}
+	}
+}
+
+func TestFollowReaderWaits(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logweaver-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "app.log")
+	appendFile(t, name, "")
+	file, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fi, err := file.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newFollowReader(name, file, fi, time.Millisecond)
+
+	type result struct {
+		text string
+		err  error
+	}
+	results := make(chan result)
+	read := func() {
+		buf := make([]byte, 64)
+		n, err := r.Read(buf)
+		results <- result{string(buf[:n]), err}
+	}
+
+	// A read waits for a line to be written
+	go read()
+	time.Sleep(20 * time.Millisecond)
+	appendFile(t, name, "one\n")
+	assert.Equal(t, result{"one\n", nil}, <-results)
+
+	// ...or for the reader to be closed
+	go read()
+	time.Sleep(20 * time.Millisecond)
+	r.Close()
+	res := <-results
+	assert.Equal(t, "", res.text)
+	assert.Error(t, res.err)
+}
+
+func TestMergerFollow(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logweaver-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Lines from the future, so that they are held back for the reorder window
+	// rather than going out as soon as they're read
+	base := time.Now().UTC().Add(time.Hour)
+	line := func(secs int, text string) string {
+		return base.Add(time.Duration(secs)*time.Second).Format("2006-01-02 15:04:05") + " " + text
+	}
+	a, b := filepath.Join(dir, "a.log"), filepath.Join(dir, "b.log")
+	appendFile(t, a, line(0, "a one")+"\n")
+	appendFile(t, b, line(1, "b one")+"\n")
+
+	conf, err := DecodeConfig(strings.NewReader(testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcs, err := OpenSources([]string{a, b}, OpenOptions{Follow: true, PollInterval: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srcs.Close()
+
+	m := NewMerger(conf, srcs, Options{
+		Follow:        true,
+		ReorderWindow: 300 * time.Millisecond,
+		PollInterval:  time.Millisecond,
+	})
+	next := func() string {
+		rec, err := m.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rec.Text
+	}
+	assert.Equal(t, line(0, "a one"), next())
+	assert.Equal(t, line(1, "b one"), next())
+
+	// Lines written while following, out of order across the files, but within
+	// the reorder window
+	appendFile(t, b, line(3, "b two")+"\n")
+	time.Sleep(50 * time.Millisecond)
+	appendFile(t, a, line(2, "a two")+"\n")
+	assert.Equal(t, line(2, "a two"), next())
+	assert.Equal(t, line(3, "b two"), next())
+
+	m.Close()
+	_, err = m.Next()
+	assert.Equal(t, io.EOF, err)
+}
@@ -0,0 +1,473 @@
+package weaver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/araddon/dateparse"
+)
+
+// The number of lines of a source that LearnSource learns from
+const learnLines = 100
+
+// The most fields, separated by spaces, that a timestamp is looked for across
+// e.g. Mon, 02 Jan 2006 15:04:05 -0700 is six
+const learnFields = 7
+
+// learnLayouts are tried on each candidate timestamp before dateparse, so
+// that common timestamps get the layout a person would write.
+var learnLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04:05.000Z0700",
+	"2006-01-02T15:04:05.000000",
+	"2006-01-02T15:04:05.000",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05.000000",
+	"2006-01-02 15:04:05.000",
+	"2006-01-02 15:04:05,000",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04:05.000 -0700",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05.000 MST",
+	"2006-01-02 15:04:05 MST",
+	"2006/01/02 15:04:05.000000",
+	"2006/01/02 15:04:05",
+	"02/Jan/2006:15:04:05 -0700",
+	"2-Jan-2006::15:04:05.000",
+	"02-Jan-2006 15:04:05.000",
+	"Jan _2 15:04:05.000000",
+	"Jan _2 15:04:05.000",
+	"Jan _2 15:04:05",
+	"Jan _2 2006 15:04:05",
+	"Mon Jan _2 15:04:05 2006",
+	"Mon Jan _2 15:04:05 MST 2006",
+	"Mon, 02 Jan 2006 15:04:05 -0700",
+	"Mon, 02 Jan 2006 15:04:05 MST",
+	"01/02/2006 15:04:05",
+	"02.01.2006 15:04:05",
+	"20060102 15:04:05.000",
+	"20060102 15:04:05",
+	"15:04:05.000000",
+}
+
+// layoutElements are the elements of a Go layout and regexes for the text
+// they match, longest first - see https://golang.org/pkg/time/#pkg-constants
+var layoutElements = []struct {
+	layout string
+	re     string
+}{
+	{"January", `[A-Z][a-z]+`},
+	{"Monday", `[A-Z][a-z]+`},
+	{"Z07:00", `(?:Z|[+-]\d\d:\d\d)`},
+	{"-07:00", `[+-]\d\d:\d\d`},
+	{"Z0700", `(?:Z|[+-]\d{4})`},
+	{"-0700", `[+-]\d{4}`},
+	{"2006", `\d{4}`},
+	{"Z07", `(?:Z|[+-]\d\d)`},
+	{"-07", `[+-]\d\d`},
+	{"Jan", `[A-Z][a-z]{2}`},
+	{"Mon", `[A-Z][a-z]{2}`},
+	{"MST", `[A-Z]{2,5}`},
+	{"002", `\d{3}`},
+	{"_2", `[ \d]?\d`},
+	{"01", `\d\d`},
+	{"02", `\d\d`},
+	{"03", `\d\d`},
+	{"04", `\d\d`},
+	{"05", `\d\d`},
+	{"06", `\d\d`},
+	{"15", `\d\d`},
+	{"PM", `[AP]M`},
+	{"pm", `[ap]m`},
+	{"1", `\d{1,2}`},
+	{"2", `\d{1,2}`},
+	{"3", `\d{1,2}`},
+	{"4", `\d{1,2}`},
+	{"5", `\d{1,2}`},
+}
+
+// epochRE matches a count since the Unix epoch of seconds, with or without a
+// fraction, or of milliseconds, microseconds or nanoseconds.
+var epochRE = regexp.MustCompile(`(?:^|[^\d.])(\d{10}(?:\.\d{1,9})?|\d{13}|\d{16}|\d{19})(?:[^\d]|$)`)
+
+// epochUnits are the units of epochRE's counts, by their number of digits
+// before any fraction.
+var epochUnits = map[int]string{
+	10: UnitSeconds,
+	13: UnitMilliseconds,
+	16: UnitMicroseconds,
+	19: UnitNanoseconds,
+}
+
+// epochPatterns are regexes for a count of each unit.
+var epochPatterns = map[string]string{
+	UnitSeconds:      `\d{10}(?:\.\d+)?`,
+	UnitMilliseconds: `\d{13}`,
+	UnitMicroseconds: `\d{16}`,
+	UnitNanoseconds:  `\d{19}`,
+}
+
+// numericRE matches a timestamp written as numbers, year first, separated by
+// punctuation e.g. 2020/10/05-16:06:10.123
+var numericRE = regexp.MustCompile(`^(\d{4})([^\d\s])(\d\d)([^\d\s])(\d\d)([^\d]{1,2})(\d\d)(:?)(\d\d)(:?)(\d\d)(?:([.,])(\d+))?$`)
+
+// zoneWordRE matches a word that may be a timezone abbreviation, e.g. in a
+// layout found by dateparse, which keeps them as they are
+var zoneWordRE = regexp.MustCompile(`\b[A-Z]{2,5}\b`)
+
+// keyedRE matches the key of a value at the end of a line's text before it,
+// e.g. time=" or "ts": "
+var keyedRE = regexp.MustCompile(`(?:"\w+"\s*:\s*"?|\b\w+=["']?)$`)
+
+// Lesson is a rule proposed by Learn for logs with lines like those it was
+// given, along with how well the rule did on them.
+type Lesson struct {
+	Config       *Config   // a config holding just the proposed rule
+	Rule         *Match    // the proposed rule
+	Example      string    // the line the rule was learned from
+	ExampleMatch [2]int    // the start and end of the timestamp in Example
+	ExampleTime  time.Time // the timestamp of Example, as parsed by the rule
+	Lines        int       // the number of lines learned from
+	Parsed       int       // lines the rule gets a timestamp from
+}
+
+// learned is a timestamp found in a line, and how to find and parse it.
+type learned struct {
+	start, end int
+	pattern    string // a regex for the timestamp, with the text before it
+	layout     string // if set, the Go layout of the timestamp
+	unit       string // if set, the timestamp is a count of this unit since the Unix epoch
+}
+
+// LearnSource proposes a rule for src from its first lines - see Learn.
+func LearnSource(src *Source) (*Lesson, error) {
+	var lines []string
+	sc := bufio.NewScanner(src.Reader)
+	sc.Buffer(make([]byte, 65536*16), 65536*16)
+	for len(lines) < learnLines && sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", src.Name, err)
+	}
+	lesson, err := Learn(lines)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", src.Name, err)
+	}
+	return lesson, nil
+}
+
+// Learn proposes a rule for logs with lines like lines. The timestamp of each
+// line is taken to be the longest text, starting earliest, that parses as a
+// time of day - with one of a list of common layouts, or else with the layout
+// that dateparse finds for it - or that is a count since the Unix epoch. The
+// regex and format found for the most lines are proposed, with the first of
+// those lines as the rule's example.
+func Learn(lines []string) (*Lesson, error) {
+	type vote struct {
+		found *learned
+		line  string
+		count int
+	}
+	var votes []*vote
+	for _, line := range lines {
+		found := learnLine(line)
+		if found == nil {
+			continue
+		}
+		voted := false
+		for _, v := range votes {
+			if v.found.pattern == found.pattern && v.found.layout == found.layout && v.found.unit == found.unit {
+				v.count++
+				voted = true
+				break
+			}
+		}
+		if !voted {
+			votes = append(votes, &vote{found: found, line: line, count: 1})
+		}
+	}
+	if len(votes) == 0 {
+		return nil, fmt.Errorf("no timestamp found in %d line(s)", len(lines))
+	}
+	best := votes[0]
+	for _, v := range votes[1:] {
+		if v.count > best.count {
+			best = v
+		}
+	}
+
+	rule := Match{
+		Match:   best.found.pattern,
+		Format:  best.found.layout,
+		Unit:    best.found.unit,
+		Example: best.line,
+	}
+	// Through TOML and back, so the rule compiles just as it will from a config
+	var b strings.Builder
+	if err := (&Config{Match: []Match{rule}}).WriteTOML(&b); err != nil {
+		return nil, err
+	}
+	conf, err := DecodeConfig(strings.NewReader(b.String()))
+	if err != nil {
+		return nil, fmt.Errorf("could not compile the rule learned: %w", err)
+	}
+	m := &conf.Match[0]
+	tm, matches, ok := m.timestamp(best.line)
+	if !ok {
+		return nil, fmt.Errorf("the rule learned doesn't parse its own example %s", best.line)
+	}
+	start, end, _ := m.timestampSpan(matches)
+	m.Expected = tm.Format(time.RFC3339Nano)
+	m.expected = tm
+
+	res := &Lesson{
+		Config:       conf,
+		Rule:         m,
+		Example:      best.line,
+		ExampleMatch: [2]int{start, end},
+		ExampleTime:  tm,
+		Lines:        len(lines),
+	}
+	for _, line := range lines {
+		if _, _, ok := m.timestamp(line); ok {
+			res.Parsed++
+		}
+	}
+	return res, nil
+}
+
+// learnLine returns the timestamp found in line, or nil.
+func learnLine(line string) *learned {
+	var best *learned
+	better := func(start, end int) bool {
+		return best == nil || end-start > best.end-best.start || (end-start == best.end-best.start && start < best.start)
+	}
+
+	fields := learnFieldSpans(line)
+	for i := range fields {
+		for j := i; j < len(fields) && j < i+learnFields; j++ {
+			// A timestamp can span fields only where they are separated by spaces
+			if j > i && strings.Trim(line[fields[j-1][1]:fields[j][0]], " \t") != "" {
+				break
+			}
+			start, end := fields[i][0], fields[j][1]
+			// Separators that end a timestamp, e.g. 2020-10-05 16:06:10: message
+			for end > start && strings.IndexByte(",:;.- \t", line[end-1]) != -1 {
+				end--
+			}
+			if !better(start, end) {
+				continue
+			}
+			if layout, ok := learnLayout(line[start:end]); ok {
+				ts, err := layoutPattern(layout)
+				if err != nil {
+					continue
+				}
+				best = &learned{start: start, end: end, pattern: learnPrefix(line, start) + "(" + ts + ")", layout: layout}
+			}
+		}
+	}
+
+	for _, m := range epochRE.FindAllStringSubmatchIndex(line, -1) {
+		start, end := m[2], m[3]
+		count := line[start:end]
+		unit := epochUnits[len(strings.SplitN(count, ".", 2)[0])]
+		tm, err := parseEpoch(count, unit)
+		if err != nil || !plausible(tm) || !better(start, end) {
+			continue
+		}
+		best = &learned{start: start, end: end, pattern: learnPrefix(line, start) + "(" + epochPatterns[unit] + ")", unit: unit}
+	}
+	return best
+}
+
+// learnFieldSpans returns the start and end of each field of line - the text
+// between spaces, and between the brackets, quotes and other punctuation that
+// often surround a timestamp.
+func learnFieldSpans(line string) [][2]int {
+	var res [][2]int
+	start := -1
+	for i := 0; i <= len(line); i++ {
+		sep := i == len(line) || strings.IndexByte(" \t[](){}<>\"'|;=", line[i]) != -1
+		switch {
+		case sep && start != -1:
+			res = append(res, [2]int{start, i})
+			start = -1
+		case !sep && start == -1:
+			start = i
+		}
+	}
+	return res
+}
+
+// learnLayout returns the Go layout of ts, if it is a plausible timestamp
+// that includes the time of day.
+func learnLayout(ts string) (string, bool) {
+	if strings.IndexAny(ts, "0123456789") == -1 {
+		return "", false
+	}
+	for _, layout := range learnLayouts {
+		if tm, err := time.Parse(layout, ts); err == nil && plausible(tm) && samePunctuation(tm.Format(layout), ts) {
+			return layout, true
+		}
+	}
+	var layout string
+	if m := numericRE.FindStringSubmatch(ts); m != nil {
+		layout = "2006" + m[2] + "01" + m[4] + "02" + m[6] + "15" + m[8] + "04" + m[10] + "05"
+		if m[12] != "" {
+			layout += m[12] + strings.Repeat("0", len(m[13]))
+		}
+	} else {
+		var err error
+		if layout, err = dateparse.ParseFormat(ts); err != nil || !strings.Contains(layout, "04") {
+			return "", false
+		}
+		layout = zoneWordRE.ReplaceAllStringFunc(layout, func(word string) string {
+			if _, ok := defaultAbbreviations[word]; ok {
+				return "MST"
+			}
+			return word
+		})
+	}
+	if tm, err := time.Parse(layout, ts); err != nil || !plausible(tm) || !knownZone(layout, tm) {
+		return "", false
+	}
+	return layout, true
+}
+
+// plausible returns true if tm could be the time a line was logged, or is a
+// time without a year.
+func plausible(tm time.Time) bool {
+	return tm.Year() == 0 || (tm.Year() >= 1990 && tm.Year() <= 2100)
+}
+
+// samePunctuation returns true if a and b have the same punctuation, in the
+// same order - e.g. if a is b formatted by the layout parsed from it, that the
+// layout has the same separators, even those Go parses loosely, e.g. the , in
+// 15:04:05,000 for a layout of 15:04:05.000.
+func samePunctuation(a string, b string) bool {
+	punctuation := func(r rune) rune {
+		if unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			return r
+		}
+		return -1
+	}
+	return strings.Map(punctuation, a) == strings.Map(punctuation, b)
+}
+
+// knownZone returns false if tm, parsed with layout, has a timezone
+// abbreviation that isn't in the built-in table - Go accepts any three capital
+// letters as one, so it's more likely a word e.g. ERR.
+func knownZone(layout string, tm time.Time) bool {
+	if !strings.Contains(layout, "MST") {
+		return true
+	}
+	name, _ := tm.Zone()
+	_, ok := defaultAbbreviations[name]
+	return ok
+}
+
+// layoutPattern returns a regex for timestamps written with the Go layout.
+func layoutPattern(layout string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(layout); {
+		// A fractional second e.g. .000 or ,999999
+		if c := layout[i]; (c == '.' || c == ',') && i+1 < len(layout) && (layout[i+1] == '0' || layout[i+1] == '9') {
+			j := i + 1
+			for j < len(layout) && layout[j] == layout[i+1] {
+				j++
+			}
+			if j == len(layout) || layout[j] < '0' || layout[j] > '9' {
+				// Go parses either separator, whichever the layout has
+				if layout[i+1] == '0' {
+					fmt.Fprintf(&b, `[.,]\d{%d}`, j-i-1)
+				} else {
+					b.WriteString(`(?:[.,]\d+)?`)
+				}
+				i = j
+				continue
+			}
+		}
+		matched := false
+		for _, elem := range layoutElements {
+			if !strings.HasPrefix(layout[i:], elem.layout) {
+				continue
+			}
+			b.WriteString(elem.re)
+			i += len(elem.layout)
+			if elem.layout == "05" && !strings.HasPrefix(layout[i:], ".") && !strings.HasPrefix(layout[i:], ",") {
+				// Go parses a fraction of a second after the seconds, even if the
+				// layout doesn't have one
+				b.WriteString(`(?:[.,]\d+)?`)
+			}
+			matched = true
+			break
+		}
+		if !matched {
+			// Other than the T and Z of ISO 8601, these are likely words of the line
+			// that dateparse kept as they are, not part of the timestamp
+			if c := layout[i]; (c >= '0' && c <= '9') || (c >= 'A' && c <= 'Z' && c != 'T' && c != 'Z') || (c >= 'a' && c <= 'z') {
+				return "", fmt.Errorf("unexpected %c in layout %s", c, layout)
+			}
+			b.WriteString(regexp.QuoteMeta(layout[i : i+1]))
+			i++
+		}
+	}
+	return b.String(), nil
+}
+
+// learnPrefix returns a regex for the text before a timestamp at start in
+// line, general enough to match other lines of the same log - the key the
+// timestamp is the value of, or the bracket, quote or bar it follows, or else the
+// number of fields before it.
+func learnPrefix(line string, start int) string {
+	before := line[:start]
+	switch {
+	case start == 0:
+		return "^"
+	case keyedRE.MatchString(before):
+		key := keyedRE.FindString(before)
+		if key[0] == '"' {
+			return regexp.QuoteMeta(key)
+		}
+		return `\b` + regexp.QuoteMeta(key)
+	case strings.IndexByte("[(<{\"'|", before[len(before)-1]) != -1:
+		return regexp.QuoteMeta(before[len(before)-1:])
+	case before[len(before)-1] == ' ' || before[len(before)-1] == '\t':
+		return fmt.Sprintf(`^(?:\S+\s+){%d}`, len(strings.Fields(before)))
+	default:
+		return ""
+	}
+}
+
+// Write describes l in a human-readable form.
+func (l *Lesson) Write(w io.Writer) error {
+	const timeFormat = "2006-01-02 15:04:05.000000000 MST"
+	var b strings.Builder
+	fmt.Fprintf(&b, "  Match:         %s\n", l.Rule.Match)
+	if l.Rule.Unit != "" {
+		fmt.Fprintf(&b, "  Unit:          %s since the Unix epoch\n", l.Rule.Unit)
+	} else {
+		fmt.Fprintf(&b, "  Format:        %s\n", l.Rule.Format)
+	}
+	fmt.Fprintf(&b, "  Example:       %s\n", l.Example)
+	fmt.Fprintf(&b, "                 %s%s\n",
+		strings.Repeat(" ", len([]rune(l.Example[:l.ExampleMatch[0]]))),
+		strings.Repeat("^", len([]rune(l.Example[l.ExampleMatch[0]:l.ExampleMatch[1]]))),
+	)
+	fmt.Fprintf(&b, "  Parsed as:     %s", l.ExampleTime.UTC().Format(timeFormat))
+	if l.ExampleTime.Year() == 0 {
+		fmt.Fprintf(&b, " (no year - it is inferred when merging)")
+	}
+	fmt.Fprintf(&b, "\n")
+	fmt.Fprintf(&b, "  With time:     %d of %d lines\n", l.Parsed, l.Lines)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,149 @@
+package weaver
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLearnLine(t *testing.T) {
+	tests := []struct {
+		line   string
+		match  string
+		format string
+		unit   string
+		want   string // the example's time, in RFC 3339
+	}{
+		{
+			line:   "2020-10-05 16:06:10,123 INFO started",
+			match:  `^(\d{4}-\d\d-\d\d \d\d:\d\d:\d\d[.,]\d{3})`,
+			format: "2006-01-02 15:04:05,000",
+			want:   "2020-10-05T16:06:10.123Z",
+		},
+		{
+			line:   "Oct  5 16:06:10 host sshd[1]: accepted",
+			match:  `^([A-Z][a-z]{2} [ \d]?\d \d\d:\d\d:\d\d(?:[.,]\d+)?)`,
+			format: "Jan _2 15:04:05",
+			want:   "0000-10-05T16:06:10Z",
+		},
+		{
+			line:   "[05/Oct/2020:16:06:10 +0200] GET /",
+			match:  `\[(\d\d/[A-Z][a-z]{2}/\d{4}:\d\d:\d\d:\d\d(?:[.,]\d+)? [+-]\d{4})`,
+			format: "02/Jan/2006:15:04:05 -0700",
+			want:   "2020-10-05T14:06:10Z",
+		},
+		{
+			line:   `{"level":"info","ts":"2020-10-05T16:06:10.5Z","msg":"x"}`,
+			match:  `"ts":"(\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d(?:[.,]\d+)?(?:Z|[+-]\d\d:\d\d))`,
+			format: "2006-01-02T15:04:05.999999999Z07:00",
+			want:   "2020-10-05T16:06:10.5Z",
+		},
+		{
+			line:   `time="2020-10-05T16:06:10Z" level=info`,
+			match:  `\btime="(\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d(?:[.,]\d+)?(?:Z|[+-]\d\d:\d\d))`,
+			format: "2006-01-02T15:04:05.999999999Z07:00",
+			want:   "2020-10-05T16:06:10Z",
+		},
+		{
+			line:   "host1 app 2020-10-05T16:06:10+01:00 msg",
+			match:  `^(?:\S+\s+){2}(\d{4}-\d\d-\d\dT\d\d:\d\d:\d\d(?:[.,]\d+)?(?:Z|[+-]\d\d:\d\d))`,
+			format: "2006-01-02T15:04:05.999999999Z07:00",
+			want:   "2020-10-05T15:06:10Z",
+		},
+		{
+			line:   "Mon Oct  5 16:06:10 PDT 2020 something",
+			match:  `^([A-Z][a-z]{2} [A-Z][a-z]{2} [ \d]?\d \d\d:\d\d:\d\d(?:[.,]\d+)? [A-Z]{2,5} \d{4})`,
+			format: "Mon Jan _2 15:04:05 MST 2006",
+			want:   "2020-10-05T23:06:10Z",
+		},
+		{
+			line:  "1601914000.123 event",
+			match: `^(\d{10}(?:\.\d+)?)`,
+			unit:  "s",
+			want:  "2020-10-05T16:06:40.123Z",
+		},
+		{
+			line:  "1601914000123 event",
+			match: `^(\d{13})`,
+			unit:  "ms",
+			want:  "2020-10-05T16:06:40.123Z",
+		},
+	}
+	for _, test := range tests {
+		l, err := Learn([]string{test.line})
+		if !assert.NoError(t, err, test.line) {
+			continue
+		}
+		assert.Equal(t, test.match, l.Rule.Match, test.line)
+		assert.Equal(t, test.format, l.Rule.Format, test.line)
+		assert.Equal(t, test.unit, l.Rule.Unit, test.line)
+		assert.Equal(t, test.want, l.ExampleTime.UTC().Format(time.RFC3339Nano), test.line)
+		assert.Equal(t, test.line, l.Rule.Example, test.line)
+		assert.Equal(t, 1, l.Parsed, test.line)
+		// The rule passes its own check, with the time it was learned as
+		assert.Empty(t, l.Config.Check(), test.line)
+	}
+}
+
+func TestLearn(t *testing.T) {
+	// As from --learn-line, or the first lines of a log
+	lines := []string{
+		"starting up",
+		"Oct  5 16:06:09 host started at 2020-10-05 16:06:09,000",
+		"2020-10-05 16:06:10,123 INFO running",
+		"  a detail",
+		"2020-10-05 16:06:11,456 INFO stopping",
+	}
+	l, err := Learn(lines)
+	if !assert.NoError(t, err) {
+		return
+	}
+	// The layout found for the most lines wins, with the first of them as the example
+	assert.Equal(t, "2006-01-02 15:04:05,000", l.Rule.Format)
+	assert.Equal(t, lines[2], l.Example)
+	assert.Equal(t, [2]int{0, 23}, l.ExampleMatch)
+	assert.Equal(t, 5, l.Lines)
+	assert.Equal(t, 2, l.Parsed)
+
+	var b strings.Builder
+	if assert.NoError(t, l.Write(&b)) {
+		assert.Equal(t, `  Match:         ^(\d{4}-\d\d-\d\d \d\d:\d\d:\d\d[.,]\d{3})
+  Format:        2006-01-02 15:04:05,000
+  Example:       2020-10-05 16:06:10,123 INFO running
+                 ^^^^^^^^^^^^^^^^^^^^^^^
+  Parsed as:     2020-10-05 16:06:10.123000000 UTC
+  With time:     2 of 5 lines
+`, b.String())
+	}
+}
+
+func TestLearnNothing(t *testing.T) {
+	_, err := Learn([]string{"no time here", "nor here"})
+	assert.EqualError(t, err, "no timestamp found in 2 line(s)")
+
+	_, err = Learn(nil)
+	assert.Error(t, err)
+
+	src, err := NewSource("app.log", strings.NewReader("no time here\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	_, err = LearnSource(src)
+	assert.EqualError(t, err, "app.log: no timestamp found in 1 line(s)")
+}
+
+func TestLearnSource(t *testing.T) {
+	src, err := NewSource("app.log", strings.NewReader("1601914000123 one\n1601914001123 two\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	l, err := LearnSource(src)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "ms", l.Rule.Unit)
+		assert.Equal(t, 2, l.Parsed)
+	}
+}
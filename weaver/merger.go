@@ -0,0 +1,373 @@
+package weaver
+
+import (
+	"container/heap"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Options control how a Merger extracts timestamps and which records it yields.
+type Options struct {
+	After            time.Time // only yield records from after this point in time
+	ReplaceTimestamp bool      // replace the timestamp in each line with ReplaceToken
+	ReplaceToken     string    // e.g. <T>, for narrower output
+	Warnings         io.Writer // if not nil, told about unparsed lines skipped at the start of a source
+
+	Follow        bool                      // keep merging as followed sources grow, rather than stopping at the end
+	ReorderWindow time.Duration             // when following, how long to hold back a line in case an earlier one turns up in another source
+	PollInterval  time.Duration             // when following, how often to check idle sources for new lines
+	Rescan        func() ([]*Source, error) // if not nil, called periodically when following to find new sources
+}
+
+// Record is a single log line, yielded by a Merger in chronological order.
+type Record struct {
+	Time         time.Time         // the computed timestamp, including the source's offset
+	Source       *Source           // where the line came from
+	Text         string            // the line, maybe with the timestamp replaced by a short token; a multi-line record's lines are joined by \n
+	Continuation bool              // true if this line is a continuation of the previous line's log message
+	Rule         *Match            // the rule used to extract timestamps from the source
+	Fields       map[string]string // the text of the rule's named groups, e.g. level or host, other than the timestamp; nil if the line has no timestamp of its own
+}
+
+// When following, the number of polls between calls to Options.Rescan.
+const rescanPolls = 10
+
+// state tracks the progress of a Merger through one source.
+type state struct {
+	parsedLine                   // the current line
+	src        *Source           // where the lines come from
+	idx        int               // position of the source on the command line, to break ties between equal timestamps
+	parser     *parser           // reads ahead of the merge in its own goroutine
+	lines      chan []parsedLine // batches of lines from the parser
+	batch      []parsedLine      // the remainder of the current batch
+}
+
+// stateHeap is a priority queue of sources, ordered by the timestamp of each
+// source's current line. Sources with equal timestamps are ordered as they
+// were given to NewMerger.
+type stateHeap []*state
+
+func (h stateHeap) Len() int { return len(h) }
+
+func (h stateHeap) Less(i, j int) bool {
+	if h[i].tm.Equal(h[j].tm) {
+		return h[i].idx < h[j].idx
+	}
+	return h[i].tm.Before(h[j].tm)
+}
+
+func (h stateHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *stateHeap) Push(x interface{}) { *h = append(*h, x.(*state)) }
+
+func (h *stateHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return x
+}
+
+// Merger interleaves the lines of several sources in chronological order.
+type Merger struct {
+	conf       *Config
+	abbrevs    abbreviations // offsets of timezone abbreviations, from the config
+	srcs       []*Source
+	opts       Options
+	states     []*state      // every source, in order
+	heap       stateHeap     // sources with a line ready to emit, earliest first
+	waiting    []*state      // followed sources with no line ready yet
+	last       *state        // the source that emitted the previous line; it needs a new line
+	started    bool          // true once the first line of every source has been read
+	done       chan struct{} // closed to stop the parsers early
+	lastRescan time.Time     // when Options.Rescan was last called
+	beginning  bool          // true while the first line of every source is being read
+	held       []string      // warnings from the beginning of the merge, not yet written
+}
+
+// NewMerger returns a Merger that reads each of srcs, using the rules in conf
+// to find the timestamp of each line.
+func NewMerger(conf *Config, srcs []*Source, opts Options) *Merger {
+	m := &Merger{
+		conf:    conf,
+		abbrevs: newAbbreviations(conf.offsets),
+		srcs:    srcs,
+		opts:    opts,
+		heap:    make(stateHeap, 0, len(srcs)),
+		done:    make(chan struct{}),
+	}
+	if m.opts.PollInterval == 0 {
+		m.opts.PollInterval = defaultPollInterval
+	}
+	for i, src := range srcs {
+		m.heap = append(m.heap, m.newState(src, i))
+	}
+	return m
+}
+
+func (m *Merger) newState(src *Source, idx int) *state {
+	if src.Location == nil {
+		src.Location = m.conf.location(src)
+	}
+	s := &state{
+		src:    src,
+		idx:    idx,
+		parser: newParser(src, m.conf.rulesFor(src), m.abbrevs, &m.opts),
+		lines:  make(chan []parsedLine, parseBatches),
+	}
+	m.states = append(m.states, s)
+	return s
+}
+
+// Close stops reading from the sources, if the merge has not run to the end.
+// It does not close the sources themselves.
+func (m *Merger) Close() error {
+	select {
+	case <-m.done:
+	default:
+		close(m.done)
+	}
+	return nil
+}
+
+// Sources returns the sources being merged, in their original order.
+func (m *Merger) Sources() []*Source {
+	return m.srcs
+}
+
+// Next returns the next line in chronological order, or io.EOF once every
+// source has been read. When following, Next waits for more lines instead, and
+// only returns io.EOF if the Merger is closed or no source can grow.
+func (m *Merger) Next() (*Record, error) {
+	if !m.started {
+		m.begin()
+	}
+	m.releaseWarnings()
+	if m.last != nil {
+		s := m.last
+		m.last = nil
+		m.advance(s, true)
+		if s.continuation && !s.eof && !s.idle {
+			// A continuation is associated with the line just emitted, so it goes
+			// out next regardless of the timestamps of the other sources.
+			return m.emit(s), nil
+		}
+		m.place(s)
+	}
+
+	if !m.opts.Follow {
+		if len(m.heap) == 0 {
+			return nil, io.EOF
+		}
+		return m.emit(heap.Pop(&m.heap).(*state)), nil
+	}
+
+	for {
+		m.poll()
+		if len(m.heap) == 0 && len(m.waiting) == 0 {
+			return nil, io.EOF
+		}
+		if len(m.heap) > 0 {
+			// If every source has a line ready, the earliest can go. Otherwise, an idle
+			// source might yet produce an earlier line, so hold back lines that are within
+			// the reorder window - either by their timestamp, or by when they were read.
+			s := m.heap[0]
+			now := time.Now()
+			if len(m.waiting) == 0 ||
+				s.tm.Before(now.Add(-m.opts.ReorderWindow)) ||
+				now.Sub(s.read) >= m.opts.ReorderWindow {
+				return m.emit(heap.Pop(&m.heap).(*state)), nil
+			}
+		}
+		select {
+		case <-m.done:
+			return nil, io.EOF
+		case <-time.After(m.opts.PollInterval):
+		}
+	}
+}
+
+// begin starts reading every source, and waits until each has its first line
+// ready, or is known to be idle or empty.
+func (m *Merger) begin() {
+	if m.started {
+		return
+	}
+	m.started = true
+	m.lastRescan = time.Now()
+	pending := m.heap
+	m.heap = m.heap[:0]
+	m.beginning = true
+	m.prepare(pending)
+	m.start(pending)
+	m.beginning = false
+}
+
+// warnf writes a warning to Options.Warnings, if set. Warnings from the
+// beginning of the merge are held back until the first call to Next, so that
+// they follow anything a Sink writes when it begins, e.g. the list of sources.
+func (m *Merger) warnf(format string, args ...interface{}) {
+	switch {
+	case m.opts.Warnings == nil:
+	case m.beginning:
+		m.held = append(m.held, fmt.Sprintf(format, args...))
+	default:
+		fmt.Fprintf(m.opts.Warnings, format, args...)
+	}
+}
+
+// releaseWarnings writes the warnings held back by warnf.
+func (m *Merger) releaseWarnings() {
+	for _, warning := range m.held {
+		fmt.Fprint(m.opts.Warnings, warning)
+	}
+	m.held = nil
+}
+
+// prepare reads the first lines of each of states.
+// A source whose timestamps don't include the year, and which has no time of
+// its own to take the year from, takes it from a neighbouring source whose
+// timestamps do - one in the same directory or archive. A source whose
+// timestamps count from boot takes its boot time from the other sources, if
+// it wasn't given one.
+func (m *Merger) prepare(states []*state) {
+	var wg sync.WaitGroup
+	for _, s := range states {
+		wg.Add(1)
+		go func(p *parser) {
+			defer wg.Done()
+			p.prepare()
+		}(s.parser)
+	}
+	wg.Wait()
+
+	for _, s := range states {
+		if s.src.stream || s.src.Modified.After(earliestModified) {
+			continue
+		}
+		bundle := bundleOf(s.src.Name)
+		for _, other := range states {
+			if other != s && !other.parser.sampleTime.IsZero() && bundleOf(other.src.Name) == bundle {
+				s.parser.yearHint = yearHint{tm: other.parser.sampleTime}
+				break
+			}
+		}
+	}
+
+	m.findBootTimes(states)
+}
+
+// start launches a parser for each of states, and waits for each to produce
+// its first line.
+func (m *Merger) start(states []*state) {
+	for _, s := range states {
+		go s.parser.run(s.lines, m.done)
+	}
+	for _, s := range states {
+		m.advance(s, true)
+		m.place(s)
+	}
+}
+
+// place puts s in the heap if it has a line ready, or in the waiting list if
+// it's an idle followed source.
+func (m *Merger) place(s *state) {
+	switch {
+	case s.eof:
+		// Drop log files that we've reached the end of.
+	case s.idle:
+		m.waiting = append(m.waiting, s)
+	default:
+		heap.Push(&m.heap, s)
+	}
+}
+
+// poll checks whether any idle sources have new lines, and looks for new
+// sources if it's time to.
+func (m *Merger) poll() {
+	var cur int
+	for _, s := range m.waiting {
+		if m.advance(s, false) && !s.idle {
+			m.place(s)
+			continue
+		}
+		if s.eof {
+			continue
+		}
+		m.waiting[cur] = s
+		cur++
+	}
+	m.waiting = m.waiting[:cur]
+
+	if m.opts.Rescan == nil || time.Since(m.lastRescan) < m.opts.PollInterval*rescanPolls {
+		return
+	}
+	m.lastRescan = time.Now()
+	srcs, err := m.opts.Rescan()
+	if err != nil {
+		m.warnf("Warning: problem looking for new log files: %v\n", err)
+	}
+	states := make([]*state, 0, len(srcs))
+	for _, src := range srcs {
+		states = append(states, m.newState(src, len(m.srcs)))
+		m.srcs = append(m.srcs, src)
+	}
+	m.start(states)
+}
+
+// advance moves s on to the next line produced by its parser. If block is
+// false, and no line is ready yet, advance returns false and leaves s alone.
+func (m *Merger) advance(s *state, block bool) bool {
+	if len(s.batch) == 0 {
+		var batch []parsedLine
+		var ok bool
+		if block {
+			batch, ok = <-s.lines
+		} else {
+			select {
+			case batch, ok = <-s.lines:
+			default:
+				return false
+			}
+		}
+		if !ok {
+			s.parsedLine = parsedLine{eof: true}
+			return true
+		}
+		s.batch = batch
+	}
+	s.parsedLine = s.batch[0]
+	s.batch = s.batch[1:]
+	if !s.eof && !s.idle {
+		rule := s.parser.rules[s.reIdx]
+		if s.src.zone == nil {
+			s.src.zone = s.src.location(rule)
+		}
+		s.src.fromBoot = rule.Since == SinceBoot
+	}
+	if s.warn {
+		m.warnf("Warning: skipping unparsed lines from start of %s...\n", s.src.Name)
+	}
+	if s.err != nil {
+		m.warnf("Warning: problem reading %s: %v\n", s.src.Name, s.err)
+	}
+	return true
+}
+
+// emit builds a record from the current line of s, which will then need a new
+// line before it can rejoin the merge.
+func (m *Merger) emit(s *state) *Record {
+	rec := &Record{
+		Time:         s.tm,
+		Source:       s.src,
+		Text:         s.line,
+		Continuation: s.continuation,
+		Rule:         s.parser.rules[s.reIdx],
+		Fields:       s.fields,
+	}
+	m.last = s
+	return rec
+}
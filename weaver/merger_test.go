@@ -0,0 +1,226 @@
+package weaver
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testRules = `
+[[match]]
+name = 'iso'
+match = '^(\d{4}-\d\d-\d\d \d\d:\d\d:\d\d) '
+format = '2006-01-02 15:04:05'
+`
+
+// testEntry is a line with a timestamp, followed by lines without one.
+type testEntry struct {
+	tm    time.Time
+	lines []string
+}
+
+// testLog returns the text of a log holding entries, called name.
+func testLog(name string, entries []testEntry) string {
+	var b strings.Builder
+	for i, e := range entries {
+		fmt.Fprintf(&b, "%s %s line %d\n", e.tm.Format("2006-01-02 15:04:05"), name, i)
+		for _, line := range e.lines {
+			fmt.Fprintf(&b, "%s\n", line)
+		}
+	}
+	return b.String()
+}
+
+// sequentialMerge merges logs one entry at a time: the entry with the earliest
+// timestamp goes next, and of entries with equal timestamps, the one from the
+// log given first. Lines without a timestamp go out with the entry before them.
+func sequentialMerge(names []string, logs [][]testEntry) []string {
+	var res []string
+	next := make([]int, len(logs))
+	for {
+		best := -1
+		for i, entries := range logs {
+			if next[i] == len(entries) {
+				continue
+			}
+			if best == -1 || entries[next[i]].tm.Before(logs[best][next[best]].tm) {
+				best = i
+			}
+		}
+		if best == -1 {
+			return res
+		}
+		e := logs[best][next[best]]
+		res = append(res, fmt.Sprintf("%s|%s %s line %d", names[best], e.tm.Format("2006-01-02 15:04:05"), names[best], next[best]))
+		for _, line := range e.lines {
+			res = append(res, names[best]+"|"+line)
+		}
+		next[best]++
+	}
+}
+
+// mergeLogs merges logs with a Merger, and returns each record as the name of
+// its source and its text.
+func mergeLogs(t *testing.T, names []string, logs [][]testEntry) []string {
+	texts := make([]string, len(logs))
+	for i, entries := range logs {
+		texts[i] = testLog(names[i], entries)
+	}
+	return mergeTexts(t, testRules, names, texts, Options{})
+}
+
+// mergeTexts merges the logs with the given texts, using rules, and returns
+// each record as the name of its source and its text.
+func mergeTexts(t *testing.T, rules string, names []string, texts []string, opts Options) []string {
+	conf, err := DecodeConfig(strings.NewReader(rules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcs := make(Sources, 0, len(texts))
+	for i, text := range texts {
+		src, err := NewSource(names[i], strings.NewReader(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		srcs = append(srcs, src)
+	}
+	defer srcs.Close()
+
+	m := NewMerger(conf, srcs, opts)
+	defer m.Close()
+	var res []string
+	for {
+		rec, err := m.Next()
+		if err == io.EOF {
+			return res
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		res = append(res, rec.Source.Name+"|"+rec.Text)
+	}
+}
+
+func TestMergerOrder(t *testing.T) {
+	base := time.Date(2020, time.October, 5, 16, 0, 0, 0, time.UTC)
+	at := func(secs int, lines ...string) testEntry {
+		return testEntry{tm: base.Add(time.Duration(secs) * time.Second), lines: lines}
+	}
+	tests := []struct {
+		name string
+		logs [][]testEntry
+	}{
+		{
+			name: "interleaved",
+			logs: [][]testEntry{
+				{at(0), at(2), at(4)},
+				{at(1), at(3), at(5)},
+			},
+		},
+		{
+			name: "ties go to the log given first",
+			logs: [][]testEntry{
+				{at(1), at(2), at(2)},
+				{at(0), at(1), at(2)},
+				{at(1), at(2)},
+			},
+		},
+		{
+			name: "continuations stay with their line",
+			logs: [][]testEntry{
+				{at(0, "  a detail", "  another"), at(3)},
+				{at(0), at(1, "Traceback:", "  File x"), at(3, "end")},
+			},
+		},
+		{
+			name: "an empty log",
+			logs: [][]testEntry{
+				{},
+				{at(0), at(1)},
+			},
+		},
+	}
+	for _, test := range tests {
+		names := make([]string, len(test.logs))
+		for i := range names {
+			names[i] = fmt.Sprintf("log%d", i)
+		}
+		assert.Equal(t, sequentialMerge(names, test.logs), mergeLogs(t, names, test.logs), test.name)
+	}
+}
+
+func TestMergerOrderRandom(t *testing.T) {
+	base := time.Date(2020, time.October, 5, 16, 0, 0, 0, time.UTC)
+	rnd := rand.New(rand.NewSource(1))
+	for round := 0; round < 20; round++ {
+		logs := make([][]testEntry, 1+rnd.Intn(5))
+		names := make([]string, len(logs))
+		for i := range logs {
+			names[i] = fmt.Sprintf("log%d", i)
+			// Enough lines to span several of the batches handed to the merger, and
+			// few enough distinct times for plenty of ties
+			tm := base
+			for n := rnd.Intn(1000); n > 0; n-- {
+				tm = tm.Add(time.Duration(rnd.Intn(3)) * time.Second)
+				var lines []string
+				for c := rnd.Intn(4) - 2; c > 0; c-- {
+					lines = append(lines, fmt.Sprintf("  detail %d", c))
+				}
+				logs[i] = append(logs[i], testEntry{tm: tm, lines: lines})
+			}
+		}
+		assert.Equal(t, sequentialMerge(names, logs), mergeLogs(t, names, logs), "round %d", round)
+	}
+}
+
+// orderSink notes the calls made to it, in order.
+type orderSink struct {
+	events []string
+}
+
+func (o *orderSink) Begin(srcs []*Source) error {
+	o.events = append(o.events, "begin")
+	return nil
+}
+
+func (o *orderSink) Write(rec *Record) error {
+	o.events = append(o.events, rec.Text)
+	return nil
+}
+
+func TestWeaveWarningsFollowBegin(t *testing.T) {
+	conf, err := DecodeConfig(strings.NewReader(testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := NewSource("app.log", strings.NewReader("banner\n2020-10-05 16:00:00 started\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	sink := &orderSink{}
+	m := NewMerger(conf, []*Source{src}, Options{Warnings: warningWriter{sink}})
+	if assert.NoError(t, Weave(m, sink)) {
+		assert.Equal(t, []string{
+			"begin",
+			"Warning: skipping unparsed lines from start of app.log...\n",
+			"2020-10-05 16:00:00 started",
+		}, sink.events)
+	}
+}
+
+// warningWriter adds the warnings written to it to a sink's events.
+type warningWriter struct {
+	sink *orderSink
+}
+
+func (w warningWriter) Write(p []byte) (int, error) {
+	w.sink.events = append(w.sink.events, string(p))
+	return len(p), nil
+}
@@ -0,0 +1,100 @@
+package weaver
+
+import "time"
+
+// Lines read from the start of a source to decide which rule to use, and which
+// way its timestamps run
+const orderSampleLines = 100
+
+// detectRule returns the index of the rule of the highest priority that
+// yields a timestamp from any of lines and, among rules of that priority, the
+// one that does so for the most lines - along with the number of lines. Rules
+// that do equally well are preferred in order. It returns -1 if no rule yields
+// a timestamp.
+func detectRule(rules []*Match, lines []string) (int, int) {
+	best, bestHits := -1, 0
+	for i, rule := range rules {
+		if best != -1 && rule.Priority < rules[best].Priority {
+			continue
+		}
+		hits := 0
+		for _, line := range lines {
+			if _, _, ok := rule.timestamp(line); ok {
+				hits++
+			}
+		}
+		if hits == 0 {
+			continue
+		}
+		if best == -1 || rule.Priority > rules[best].Priority || hits > bestHits {
+			best, bestHits = i, hits
+		}
+	}
+	return best, bestHits
+}
+
+// descending returns true if the rule says its logs run newest first, or if
+// it's left to us and the timestamps in lines mostly go backwards. For a rule
+// that groups lines into records, only the timestamps that start records count,
+// since the lines inside a record, like command output, may be in any order.
+func (m *Match) descending(lines []string) bool {
+	switch m.Order {
+	case OrderDescending:
+		return true
+	case OrderAscending:
+		return false
+	}
+
+	var up, down int
+	var prev time.Time
+	havePrev := false
+	prevEnded := true
+	for _, line := range lines {
+		if m.groupsRecords() {
+			start, end := m.recordBounds(line, prevEnded)
+			prevEnded = end
+			if !start {
+				continue
+			}
+		}
+		tm, _, ok := m.timestamp(line)
+		if !ok {
+			continue
+		}
+		if havePrev {
+			switch {
+			case tm.Before(prev):
+				down++
+			case tm.After(prev):
+				up++
+			}
+		}
+		prev = tm
+		havePrev = true
+	}
+	return down >= 2 && down > up
+}
+
+// reverseRecords reverses the order of the records in lines. A record is a
+// line for which isStart returns true, along with the lines after it up to
+// the next such line. Lines before the first record stay at the start.
+func reverseRecords(lines []string, isStart func(string) bool) []string {
+	starts := make([]int, 0, len(lines))
+	for i, line := range lines {
+		if isStart(line) {
+			starts = append(starts, i)
+		}
+	}
+	if len(starts) == 0 {
+		return lines
+	}
+
+	res := make([]string, 0, len(lines))
+	res = append(res, lines[:starts[0]]...)
+	end := len(lines)
+	for i := len(starts) - 1; i >= 0; i-- {
+		res = append(res, lines[starts[i]:end]...)
+		end = starts[i]
+	}
+	return res
+}
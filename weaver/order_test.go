@@ -0,0 +1,89 @@
+package weaver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReverseRecords(t *testing.T) {
+	isStart := func(line string) bool {
+		return strings.HasPrefix(line, "2020")
+	}
+	tests := []struct {
+		name  string
+		lines []string
+		want  []string
+	}{
+		{
+			name:  "empty",
+			lines: []string{},
+			want:  []string{},
+		},
+		{
+			name:  "no records",
+			lines: []string{"banner", "more"},
+			want:  []string{"banner", "more"},
+		},
+		{
+			name:  "single lines",
+			lines: []string{"2020 c", "2020 b", "2020 a"},
+			want:  []string{"2020 a", "2020 b", "2020 c"},
+		},
+		{
+			name:  "records keep their lines in order",
+			lines: []string{"2020 c", "c1", "c2", "2020 b", "2020 a", "a1"},
+			want:  []string{"2020 a", "a1", "2020 b", "2020 c", "c1", "c2"},
+		},
+		{
+			name:  "lines before the first record stay first",
+			lines: []string{"header", "", "2020 b", "b1", "2020 a"},
+			want:  []string{"header", "", "2020 a", "2020 b", "b1"},
+		},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, reverseRecords(test.lines, isStart), test.name)
+	}
+}
+
+func TestDetectRulePriority(t *testing.T) {
+	conf, err := DecodeConfig(strings.NewReader(`
+[[match]]
+name = 'syslog'
+match = '^(\w{3} [ 0-9]\d \d\d:\d\d:\d\d) '
+format = 'Jan _2 15:04:05'
+
+[[match]]
+name = 'record'
+match = '^(\d{4}-\d\d-\d\d \d\d:\d\d:\d\d) '
+format = '2006-01-02 15:04:05'
+priority = 5
+`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	rules := conf.rulesFor(&Source{Name: "rec.log", basename: "rec.log"})
+	lines := []string{
+		"2020-09-28 18:00:37 ERROR dump follows",
+		"Sep 28 17:01:00 inner 1",
+		"Sep 28 17:02:00 inner 2",
+	}
+
+	// The rule of higher priority wins, though the other gets more lines
+	idx, hits := detectRule(rules, lines)
+	if assert.NotEqual(t, -1, idx) {
+		assert.Equal(t, "record", rules[idx].Name)
+		assert.Equal(t, 1, hits)
+	}
+
+	// Unless it gets none
+	idx, hits = detectRule(rules, lines[1:])
+	if assert.NotEqual(t, -1, idx) {
+		assert.Equal(t, "syslog", rules[idx].Name)
+		assert.Equal(t, 2, hits)
+	}
+
+	idx, _ = detectRule(rules, []string{"no timestamp"})
+	assert.Equal(t, -1, idx)
+}
@@ -0,0 +1,438 @@
+package weaver
+
+import (
+	"bufio"
+	"time"
+)
+
+const (
+	parseBatchSize = 128 // lines handed from a parser to the merger at a time
+	parseBatches   = 4   // batches a parser may get ahead of the merger
+	redetectLines  = 100 // lines in a row without a timestamp before looking for a better rule
+)
+
+// parsedLine is a line from a source, with its timestamp extracted.
+type parsedLine struct {
+	line         string            // the line, maybe with the timestamp replaced by a short token
+	tm           time.Time         // the computed timestamp for the line
+	reIdx        int               // the rule used to extract timestamps from this source
+	continuation bool              // true if this line is a continuation of the previous line's log message
+	warn         bool              // true if unparsed lines were skipped at the start of the source before this line
+	eof          bool              // true if there are no more lines; only warn and err are meaningful
+	err          error             // if not nil, why there are no more lines
+	idle         bool              // true if a followed source has no more lines for now; only warn is meaningful
+	recordStart  bool              // true if the rule groups lines into records, and this line starts one
+	recordEnd    bool              // true if the rule groups lines into records, and this line ends one
+	fields       map[string]string // the text of the rule's named groups, other than the timestamp
+	read         time.Time         // when following, the time at which the line was read
+}
+
+// parser reads the lines of one source and extracts their timestamps. Each
+// parser runs in its own goroutine, so decompression and parsing of different
+// sources proceed in parallel, ahead of the merge.
+type parser struct {
+	src            *Source
+	rules          []*Match      // the rules that apply to the source, in the order to try them
+	abbrevs        abbreviations // offsets of timezone abbreviations
+	opts           *Options
+	scanner        *bufio.Scanner // for reading the log file line by line
+	pending        []string       // lines already read from scanner, to be parsed before any more are scanned
+	reIdx          int            // != -1 means we have figured out which regex to use to extract the timestamp for this file
+	newEnough      bool           // true if the log lines are now newer than Options.After
+	tm             time.Time      // the timestamp of the last line that had one
+	warnedSkipping bool           // if true, then we found unparseable lines at the beginning, and HAVE flagged a warning about it
+	out            chan<- []parsedLine
+	done           <-chan struct{}
+	batch          []parsedLine // lines not yet sent to the merger
+	waiting        bool         // true if we've told the merger that a followed source is idle
+	building       *parsedLine  // a multi-line record still being read
+	prevEnded      bool         // true if the last line read ended a record
+	prepared       bool         // true once prepare has been called
+	sampleTime     time.Time    // the latest timestamp that includes a year, from the lines read by prepare
+	yearHint       yearHint     // for timestamps that don't include a year
+	year           int          // the year of the last timestamp that didn't include one
+	month          time.Month   // the month of the last timestamp that didn't include a year
+	detected       int          // != -1 means prepare chose this rule from the first lines of the source
+	missed         []string     // lines in a row that the rule didn't get a timestamp from
+	stats          Explanation  // how the source's lines have been parsed so far
+	held           []*held      // lines held back to be put in order, earliest first
+	open           *held        // the held line that continuation lines are added to
+	latest         time.Time    // the latest timestamp of a held line
+	released       time.Time    // the timestamp of the last held line passed on to the merger
+}
+
+func newParser(src *Source, rules []*Match, abbrevs abbreviations, opts *Options) *parser {
+	sc := bufio.NewScanner(src.Reader)
+	sc.Buffer(make([]byte, 65536*16), 65536*16)
+	return &parser{
+		src:       src,
+		rules:     rules,
+		abbrevs:   abbrevs,
+		opts:      opts,
+		scanner:   sc,
+		reIdx:     -1,
+		detected:  -1,
+		prevEnded: true,
+		yearHint:  newYearHint(src),
+	}
+}
+
+// run sends batches of parsed lines to out until the source is exhausted, the
+// last of which holds an eof line. It returns early if done is closed.
+func (p *parser) run(out chan<- []parsedLine, done <-chan struct{}) {
+	defer close(out)
+	p.out = out
+	p.done = done
+	p.batch = make([]parsedLine, 0, parseBatchSize)
+	if p.src.follow != nil {
+		p.src.follow.setIdle(p.wait)
+	}
+	if !p.prepared {
+		p.prepare()
+	}
+	for {
+		pl := p.next()
+		switch {
+		case pl.eof:
+			p.finishRecord()
+			p.releaseHeld()
+			p.batch = append(p.batch, pl)
+		case p.rules[pl.reIdx].groupsRecords():
+			if p.building != nil && !pl.recordStart {
+				// Part of the record being read
+				p.building.line += "\n" + pl.line
+				p.building.recordEnd = pl.recordEnd
+			} else {
+				p.finishRecord()
+				p.building = &pl
+			}
+			if p.building.recordEnd {
+				p.finishRecord()
+			}
+		default:
+			p.finishRecord()
+			p.queue(pl)
+		}
+		if pl.eof || len(p.batch) >= parseBatchSize {
+			if !p.flush() || pl.eof {
+				return
+			}
+		}
+	}
+}
+
+// finishRecord queues the multi-line record being read, if there is one.
+func (p *parser) finishRecord() {
+	if p.building != nil {
+		p.queue(*p.building)
+		p.building = nil
+	}
+}
+
+// flush sends the current batch to the merger. It returns false if the merger
+// has stopped.
+func (p *parser) flush() bool {
+	select {
+	case p.out <- p.batch:
+		p.batch = make([]parsedLine, 0, parseBatchSize)
+		return true
+	case <-p.done:
+		return false
+	}
+}
+
+// wait is called by a followed source before it waits for more data. The
+// lines parsed so far are handed over, along with a marker telling the merger
+// that there may be no more from this source for a while. It returns false if
+// the merger has stopped.
+func (p *parser) wait() bool {
+	if p.waiting {
+		select {
+		case <-p.done:
+			return false
+		default:
+			return true
+		}
+	}
+	p.waiting = true
+	// A record can't be held back waiting for lines that may never come
+	p.finishRecord()
+	p.releaseHeld()
+	p.batch = append(p.batch, parsedLine{idle: true})
+	return p.flush()
+}
+
+// redetect notes line, which the source's rule didn't get a timestamp from,
+// though it could start a record. After a long run of such lines, it looks for
+// a rule that suits them better - e.g. the log's format may have changed when
+// the software was upgraded - and returns true if it switched to one.
+func (p *parser) redetect(line string) bool {
+	p.missed = append(p.missed, line)
+	if len(p.missed) < redetectLines {
+		return false
+	}
+	idx, hits := detectRule(p.rules, p.missed)
+	p.missed = p.missed[:0]
+	if idx == -1 || idx == p.reIdx || hits < redetectLines/4 {
+		return false
+	}
+	p.reIdx = idx
+	p.prevEnded = true
+	p.stats.LaterRules = append(p.stats.LaterRules, p.rules[idx])
+	return true
+}
+
+// readLine returns the next line of the source.
+func (p *parser) readLine() (string, bool) {
+	if len(p.pending) > 0 {
+		line := p.pending[0]
+		p.pending = p.pending[1:]
+		return line, true
+	}
+	if !p.scanner.Scan() {
+		return "", false
+	}
+	return p.scanner.Text(), true
+}
+
+// prepare decides whether the timestamps in the source run backwards, e.g. in
+// the output of last(1). If they do, the whole source is read, and its records
+// are queued in reverse order, so that they are parsed oldest first. It also
+// chooses the source's rule from the first lines, and notes the latest
+// timestamp in them, if they include the year.
+//
+// A followed source can't be read to the end, so it is never reversed, and
+// its rule is chosen from the first lines it held when it was opened.
+func (p *parser) prepare() {
+	p.prepared = true
+	if p.src.follow != nil {
+		p.detect(p.followedSample())
+		return
+	}
+
+	sample := make([]string, 0, orderSampleLines)
+	for len(sample) < orderSampleLines && p.scanner.Scan() {
+		sample = append(sample, p.scanner.Text())
+	}
+	p.pending = sample
+
+	rule := p.detect(sample)
+	if rule == nil || !rule.descending(sample) {
+		return
+	}
+	p.stats.Descending = true
+
+	lines := sample
+	for p.scanner.Scan() {
+		lines = append(lines, p.scanner.Text())
+	}
+	p.pending = reverseRecords(lines, func(line string) bool {
+		if rule.start != nil {
+			return rule.start.MatchString(line)
+		}
+		_, _, ok := rule.timestamp(line)
+		return ok
+	})
+}
+
+// detect chooses the rule for the source from sample, the first lines of the
+// source, and notes the latest timestamp in them if they include the year. It
+// returns the rule, or nil if no rule gets a timestamp from the lines.
+func (p *parser) detect(sample []string) *Match {
+	idx, _ := detectRule(p.rules, sample)
+	if idx == -1 {
+		return nil
+	}
+	p.detected = idx
+	rule := p.rules[idx]
+	for _, line := range sample {
+		if rule.Since == SinceBoot {
+			break // times since boot say nothing about the year
+		}
+		if tm, _, ok := rule.timestamp(line); ok && tm.Year() != 0 && tm.After(p.sampleTime) {
+			p.sampleTime = tm
+		}
+	}
+	return rule
+}
+
+// followedSample returns the first lines of a followed source, read without
+// waiting for more - or nil if the source can't be read again, e.g. a pipe.
+func (p *parser) followedSample() []string {
+	if p.src.reopen == nil {
+		return nil
+	}
+	r, err := p.src.reopen()
+	if err != nil {
+		return nil
+	}
+	defer r.Close()
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 65536*16), 65536*16)
+	sample := make([]string, 0, orderSampleLines)
+	for len(sample) < orderSampleLines && sc.Scan() {
+		sample = append(sample, sc.Text())
+	}
+	return sample
+}
+
+// next reads the next line from the source, skipping lines at the start of
+// the source until one yields a timestamp.
+func (p *parser) next() parsedLine {
+	warn := false
+	for {
+		line, ok := p.readLine()
+		if !ok {
+			return parsedLine{eof: true, warn: warn, err: p.scanner.Err()}
+		}
+		p.waiting = false
+		res := parsedLine{
+			line: line,
+		}
+		if p.opts.Follow {
+			res.read = time.Now()
+		}
+
+		foundTimestampInLine := false
+		if p.reIdx != -1 { // means we know which regex to use now
+			match := p.rules[p.reIdx]
+			var matches []int
+			if match.groupsRecords() {
+				res.recordStart, res.recordEnd = match.recordBounds(res.line, p.prevEnded)
+				p.prevEnded = res.recordEnd
+			}
+			if res.recordStart || !match.groupsRecords() {
+				// A line inside a record might have a timestamp of its own, but it's only
+				// the record's first line that counts
+				matches = match.re.FindStringSubmatchIndex(res.line)
+			}
+			parsed := false
+			if start, end, ok := match.timestampSpan(matches); ok {
+				tm, guessed, err := match.parseTimestamp(res.line[start:end], p.src.location(match), p.abbrevs)
+				if err == nil {
+					parsed = true
+					tm = p.inferYear(p.fromBoot(match, tm))
+					tm = tm.Add(p.src.Offset)
+					p.noteParsed(line, start, end, tm, guessed)
+					if tm.After(p.opts.After) {
+						p.newEnough = true
+						foundTimestampInLine = true
+						res.fields = match.fields(line, matches)
+						if tm.Before(p.released) || (!p.reorders() && tm.Before(p.tm)) {
+							// This is a strange case - here's an example:
+							//
+							// [2020-10-05 16:06:40] systemctl status mariadb -l --no-pager
+							// ● mariadb.service - MariaDB 10.4.13 database server
+							//    Loaded: loaded (/lib/systemd/system/mariadb.service; enabled; vendor preset: enabled)
+							//   Drop-In: /etc/systemd/system/mariadb.service.d
+							//            └─migrated-from-my.cnf-settings.conf
+							//    Active: active (running) since Mon 2020-10-05 16:05:55 CEST; 45s ago
+							//      Docs: man:mysqld(8)
+							//            https://mariadb.com/kb/en/library/systemd/
+							//  Main PID: 5813 (mysqld)
+							//    Status: "Taking your SQL requests now..."
+							//     Tasks: 37 (limit: 4638)
+							//    CGroup: /system.slice/mariadb.service
+							//            └─5813 /usr/sbin/mysqld --wsrep-new-cluster --wsrep_start_position=00000000-0000-0000-0000-000000000000:-1
+							//
+							// Oct 05 16:06:15 tpvm1 -innobackupex-backup[6193]: [00] 2020-10-05 16:06:15 All tables unlocked
+							// Oct 05 16:06:15 tpvm1 -innobackupex-backup[6193]: [00] 2020-10-05 16:06:15 Streaming ib_buffer_pool to <STDOUT>
+							// Oct 05 16:06:15 tpvm1 -innobackupex-backup[6193]: [00] 2020-10-05 16:06:15         ...done
+							// Oct 05 16:06:15 tpvm1 -innobackupex-backup[6193]: [00] 2020-10-05 16:06:15 Backup created in directory '/tmp/tmp.94x28Gk7N2/'
+							//
+							// The lowest lines are the output from systemctl status mariadb, and are just appended to the log. They
+							// have parseable timestamps, but they don't represent legitimate log entries. Because they'd always be
+							// earlier in time than the introducing log line, we can assume they should be treated as continuations.
+							// Note that this example wouldn't show this problem precisely, because the regex to match the introducing
+							// line would not match the false log files in the systemctl output. But they could, in principle.
+							//
+							// If the source's lines are reordered, only a line earlier than one already passed
+							// on to the merger is treated this way.
+							res.continuation = true
+							p.stats.OutOfOrder++
+						} else {
+							if tm.Before(p.tm) {
+								p.stats.Reordered++
+							}
+							p.tm = tm
+						}
+						if p.opts.ReplaceTimestamp {
+							res.line = res.line[0:start] + p.opts.ReplaceToken + res.line[end:]
+						}
+					}
+				}
+			}
+			if parsed {
+				p.missed = p.missed[:0]
+			} else if (res.recordStart || !match.groupsRecords()) && p.redetect(line) {
+				// Only a line that could start a record counts, so the rule doesn't change
+				// part way through one
+				// Try this line again with the new rule
+				p.pending = append([]string{line}, p.pending...)
+				continue
+			}
+			if !foundTimestampInLine && p.newEnough {
+				// this file has already emitted a line, and the merger will only ask for the next
+				// line once it has emitted that one. We assume if we can't parse the line, then it
+				// just belongs to the current file
+				// Use last timestamp for this file by default (don't change .tm)
+				res.continuation = true
+				foundTimestampInLine = true
+			}
+		} else {
+			// we don't know which regex to use yet for this file, so try them all - or
+			// just the one that suits the first lines of the file best
+			for mi, match := range p.rules {
+				if p.detected != -1 && mi != p.detected {
+					continue
+				}
+				matches := match.re.FindStringSubmatchIndex(res.line)
+				if start, end, ok := match.timestampSpan(matches); ok {
+					tm, guessed, err := match.parseTimestamp(res.line[start:end], p.src.location(match), p.abbrevs)
+					if err == nil {
+						p.reIdx = mi
+						p.stats.Rule = match
+						tm = p.inferYear(p.fromBoot(match, tm))
+						if match.groupsRecords() {
+							_, res.recordEnd = match.recordBounds(res.line, true)
+							res.recordStart = true
+							p.prevEnded = res.recordEnd
+						}
+						tm = tm.Add(p.src.Offset)
+						p.noteParsed(line, start, end, tm, guessed)
+						if tm.After(p.opts.After) {
+							foundTimestampInLine = true
+							p.newEnough = true
+							p.tm = tm
+							res.fields = match.fields(line, matches)
+							if p.opts.ReplaceTimestamp {
+								res.line = res.line[0:start] + p.opts.ReplaceToken + res.line[end:]
+							}
+						}
+						break
+					}
+				}
+			}
+		}
+
+		if foundTimestampInLine { // means we can stop skipping, if we were
+			res.tm = p.tm
+			res.reIdx = p.reIdx
+			res.warn = warn
+			p.noteLine(&res)
+			return res
+		}
+		p.stats.Lines++
+		p.stats.Skipped++
+
+		// If we get here without a timestamp, then either we're still trying all regexes
+		// to look for the first one that matches anything, or the established regex matched
+		// but the line is not yet newer than Options.After. In both cases, we've not emitted
+		// anything yet for this file - so we can just flag a warning, and skip this line
+		// and all subsequent until we can extract a timestamp.
+		if p.reIdx == -1 && !p.warnedSkipping {
+			warn = true
+			p.warnedSkipping = true
+		}
+	}
+}
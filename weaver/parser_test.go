@@ -0,0 +1,104 @@
+package weaver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordBounds(t *testing.T) {
+	tests := []struct {
+		rule      string
+		line      string
+		prevEnded bool
+		start     bool
+		end       bool
+	}{
+		{"start = '^\\d'", "2020-10-05 16:00:00 a", false, true, false},
+		{"start = '^\\d'", "  at Foo.bar", true, false, false},
+		{"delimiter = '^$'", "2020-10-05 16:00:00 a", true, true, false},
+		{"delimiter = '^$'", "2020-10-05 16:00:00 a", false, false, false},
+		{"delimiter = '^$'", "", false, false, true},
+		{"start = '^\\d'\ndelimiter = '^--$'", "--", false, false, true},
+	}
+	for _, test := range tests {
+		conf, err := DecodeConfig(strings.NewReader("[[match]]\nmatch = '^(.*)$'\nformat = '2006'\n" + test.rule))
+		if !assert.NoError(t, err, test.rule) {
+			continue
+		}
+		m := &conf.Match[0]
+		assert.True(t, m.groupsRecords(), test.rule)
+		start, end := m.recordBounds(test.line, test.prevEnded)
+		assert.Equal(t, test.start, start, "%s: start of %q", test.rule, test.line)
+		assert.Equal(t, test.end, end, "%s: end of %q", test.rule, test.line)
+	}
+}
+
+func TestMergeRecords(t *testing.T) {
+	const other = "2020-10-05 16:00:01 other\n2020-10-05 16:00:03 other\n"
+	tests := []struct {
+		name string
+		rule string
+		text string
+		want []string
+	}{
+		{
+			name: "start",
+			rule: `start = '^\d{4}-\d\d-\d\d \d\d:\d\d:\d\d [A-Z]+ '`,
+			text: "2020-10-05 16:00:00 ERROR dump follows\n" +
+				"2020-10-05 15:00:00 inner\n" +
+				"2020-10-05 17:00:00 inner\n" +
+				"2020-10-05 16:00:02 INFO done\n",
+			want: []string{
+				"app.log|2020-10-05 16:00:00 ERROR dump follows\n2020-10-05 15:00:00 inner\n2020-10-05 17:00:00 inner",
+				"other.log|2020-10-05 16:00:01 other",
+				"app.log|2020-10-05 16:00:02 INFO done",
+				"other.log|2020-10-05 16:00:03 other",
+			},
+		},
+		{
+			name: "delimiter",
+			rule: `delimiter = '^$'`,
+			text: "2020-10-05 16:00:00 dump follows\n" +
+				"2020-10-05 17:00:00 inner\n" +
+				"\n" +
+				"2020-10-05 16:00:02 done\n",
+			want: []string{
+				"app.log|2020-10-05 16:00:00 dump follows\n2020-10-05 17:00:00 inner\n",
+				"other.log|2020-10-05 16:00:01 other",
+				"app.log|2020-10-05 16:00:02 done",
+				"other.log|2020-10-05 16:00:03 other",
+			},
+		},
+		{
+			name: "start and delimiter",
+			rule: "start = '^BEGIN '\ndelimiter = '^END$'",
+			text: "BEGIN 2020-10-05 16:00:00 dump follows\n" +
+				"2020-10-05 17:00:00 inner\n" +
+				"END\n" +
+				"2020-10-05 16:00:02 between records\n" +
+				"BEGIN 2020-10-05 16:00:04 another\n" +
+				"END\n",
+			want: []string{
+				"app.log|BEGIN 2020-10-05 16:00:00 dump follows\n2020-10-05 17:00:00 inner\nEND",
+				// A line outside any record stays after the record before it
+				"app.log|2020-10-05 16:00:02 between records",
+				"other.log|2020-10-05 16:00:01 other",
+				"other.log|2020-10-05 16:00:03 other",
+				"app.log|BEGIN 2020-10-05 16:00:04 another\nEND",
+			},
+		},
+	}
+	for _, test := range tests {
+		rules := `
+[[match]]
+name = 'records'
+match = '^(?:BEGIN )?(\d{4}-\d\d-\d\d \d\d:\d\d:\d\d) '
+format = '2006-01-02 15:04:05'
+files = ['app.log']
+` + test.rule + testRules
+		got := mergeTexts(t, rules, []string{"app.log", "other.log"}, []string{test.text, other}, Options{})
+		assert.Equal(t, test.want, got, test.name)
+	}
+}
@@ -0,0 +1,95 @@
+package weaver
+
+import (
+	"sort"
+	"time"
+)
+
+// held is a line in a parser's reorder buffer, along with the continuation
+// lines that follow it.
+type held struct {
+	tm    time.Time
+	lines []parsedLine
+}
+
+// reorderLimits returns how far out of order the lines of the parser's source
+// may be and still be put back in order - set for the source, or else by its
+// rule. Both are zero if lines aren't reordered.
+func (p *parser) reorderLimits() (time.Duration, int) {
+	if p.src.ReorderWindow > 0 || p.src.ReorderLines > 0 {
+		return p.src.ReorderWindow, p.src.ReorderLines
+	}
+	if p.reIdx == -1 {
+		return 0, 0
+	}
+	rule := p.rules[p.reIdx]
+	return rule.reorderWindow, rule.ReorderLines
+}
+
+// reorders returns true if the lines of the parser's source are put back in
+// order when they are a little out of order.
+func (p *parser) reorders() bool {
+	window, lines := p.reorderLimits()
+	return window > 0 || lines > 0
+}
+
+// queue passes pl on to the merger. If the source's lines are reordered, pl is
+// held back, in timestamp order, until it is more than the reorder window
+// older than the latest line, or more than the reorder limit of lines is held.
+// The line read last is always held, since continuation lines may follow it.
+func (p *parser) queue(pl parsedLine) {
+	window, lines := p.reorderLimits()
+	if window <= 0 && lines <= 0 {
+		p.releaseHeld()
+		p.batch = append(p.batch, pl)
+		return
+	}
+	if pl.continuation {
+		if p.open != nil {
+			p.open.lines = append(p.open.lines, pl)
+		} else {
+			p.batch = append(p.batch, pl)
+		}
+		return
+	}
+
+	h := &held{tm: pl.tm, lines: []parsedLine{pl}}
+	i := sort.Search(len(p.held), func(i int) bool {
+		return p.held[i].tm.After(pl.tm)
+	})
+	p.held = append(p.held, nil)
+	copy(p.held[i+1:], p.held[i:])
+	p.held[i] = h
+	p.open = h
+	if pl.tm.After(p.latest) {
+		p.latest = pl.tm
+	}
+
+	for len(p.held) > 0 && p.held[0] != p.open {
+		tooMany := lines > 0 && len(p.held) > lines
+		tooOld := window > 0 && !p.held[0].tm.After(p.latest.Add(-window))
+		if !tooMany && !tooOld {
+			break
+		}
+		p.release()
+	}
+}
+
+// release passes the earliest held line on to the merger.
+func (p *parser) release() {
+	h := p.held[0]
+	p.held[0] = nil
+	p.held = p.held[1:]
+	p.batch = append(p.batch, h.lines...)
+	p.released = h.tm
+	if h == p.open {
+		p.open = nil
+	}
+}
+
+// releaseHeld passes every held line on to the merger.
+func (p *parser) releaseHeld() {
+	for len(p.held) > 0 {
+		p.release()
+	}
+}
@@ -0,0 +1,108 @@
+package weaver
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReorder(t *testing.T) {
+	// Seconds after 16:00:00 of each line of the log
+	line := func(secs int) string {
+		return fmt.Sprintf("2020-10-05 16:00:%02d line", secs)
+	}
+	tests := []struct {
+		name   string
+		rule   string        // added to the rule
+		window time.Duration // for the source, as set on the command line
+		lines  int
+		log    []int
+		want   []string // continuations start with +
+	}{
+		{
+			name: "not reordered",
+			log:  []int{0, 2, 1, 3},
+			want: []string{line(0), line(2), "+" + line(1), line(3)},
+		},
+		{
+			name: "by window",
+			rule: "reorder_window = '1s'",
+			log:  []int{0, 2, 1, 3},
+			want: []string{line(0), line(1), line(2), line(3)},
+		},
+		{
+			name: "further out than the window",
+			rule: "reorder_window = '1s'",
+			log:  []int{0, 3, 4, 1, 5},
+			want: []string{line(0), line(3), line(4), "+" + line(1), line(5)},
+		},
+		{
+			name: "by lines",
+			rule: "reorder_lines = 2",
+			log:  []int{0, 3, 4, 1, 5},
+			want: []string{line(0), line(1), line(3), line(4), line(5)},
+		},
+		{
+			name: "further out than the lines",
+			rule: "reorder_lines = 1",
+			log:  []int{0, 3, 4, 1, 5},
+			want: []string{line(0), line(3), line(4), "+" + line(1), line(5)},
+		},
+		{
+			name:   "set for the source",
+			rule:   "reorder_window = '1s'",
+			window: 5 * time.Second,
+			log:    []int{0, 3, 4, 1, 5},
+			want:   []string{line(0), line(1), line(3), line(4), line(5)},
+		},
+		{
+			name: "continuations move with their line",
+			rule: "reorder_window = '5s'",
+			log:  []int{0, 2, -1, 1, -1, 3},
+			want: []string{line(0), line(1), "+  detail", line(2), "+  detail", line(3)},
+		},
+	}
+	for _, test := range tests {
+		conf, err := DecodeConfig(strings.NewReader(testRules + test.rule + "\n"))
+		if !assert.NoError(t, err, test.name) {
+			continue
+		}
+		var text strings.Builder
+		for _, secs := range test.log {
+			if secs < 0 {
+				text.WriteString("  detail\n")
+			} else {
+				text.WriteString(line(secs) + "\n")
+			}
+		}
+		src, err := NewSource("app.log", strings.NewReader(text.String()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		src.ReorderWindow, src.ReorderLines = test.window, test.lines
+
+		m := NewMerger(conf, []*Source{src}, Options{})
+		var got []string
+		for {
+			rec, err := m.Next()
+			if err == io.EOF {
+				break
+			}
+			if !assert.NoError(t, err, test.name) {
+				break
+			}
+			if rec.Continuation {
+				got = append(got, "+"+rec.Text)
+			} else {
+				got = append(got, rec.Text)
+			}
+		}
+		assert.Equal(t, test.want, got, test.name)
+		m.Close()
+		src.Close()
+	}
+}
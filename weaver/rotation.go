@@ -0,0 +1,216 @@
+package weaver
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+var (
+	// e.g. syslog.1, syslog.2.gz
+	rotatedNumberRe = regexp.MustCompile(`^(.+)\.([0-9]+)$`)
+	// e.g. syslog-20201015, syslog-2020101512.gz (logrotate's dateext)
+	rotatedDateRe = regexp.MustCompile(`^(.+)-((?:19|20)[0-9]{6}(?:[0-9]{2})?)$`)
+	// e.g. ss-10.0.0 or app-1.2 - a name like ss-10.0.0.1 is an address or a
+	// version, not a rotated log
+	numericBaseRe = regexp.MustCompile(`\.[0-9]+$`)
+)
+
+// rotated is a file that may be one of a set of rotated logs.
+type rotated struct {
+	src    *Source
+	number int    // e.g. 2 for syslog.2.gz; higher is older
+	date   string // e.g. 20201015 for syslog-20201015; lower is older
+}
+
+// older returns true if r was rotated before other.
+func (r rotated) older(other rotated) bool {
+	switch {
+	case r.date != "" && other.date != "":
+		return r.date < other.date
+	case r.date != "" || other.date != "":
+		// Mixed schemes; assume dated files are older
+		return r.date != ""
+	default:
+		return r.number > other.number
+	}
+}
+
+// rotationOf returns the name of the log that the file called name was
+// rotated from - which is name itself if it's the current log - and where the
+// file comes in the rotation.
+func rotationOf(src *Source) (string, rotated) {
+	dir, file := filepath.Split(src.Name)
+	file = trimCompressionExt(file)
+	if m := rotatedNumberRe.FindStringSubmatch(file); m != nil && !numericBaseRe.MatchString(m[1]) {
+		n, err := strconv.Atoi(m[2])
+		if err == nil {
+			return dir + m[1], rotated{src: src, number: n}
+		}
+	}
+	if m := rotatedDateRe.FindStringSubmatch(file); m != nil {
+		return dir + m[1], rotated{src: src, date: m[2]}
+	}
+	// The current log. number -1 sorts it after every numbered file.
+	return src.Name, rotated{src: src, number: -1}
+}
+
+// isRotation returns true if set, files named like rotations of the same log,
+// really are a set of rotated logs - the current log is among them, or else
+// the numbered files run from 1 without a gap, as logrotate leaves them.
+func isRotation(set []rotated) bool {
+	numbers := make([]int, 0, len(set))
+	for _, r := range set {
+		switch {
+		case r.number == -1:
+			return true
+		case r.date == "":
+			numbers = append(numbers, r.number)
+		}
+	}
+	sort.Ints(numbers)
+	for i, n := range numbers {
+		if n != i+1 {
+			return false
+		}
+	}
+	return true
+}
+
+// chainRotated looks for sets of rotated logs in srcs, e.g. syslog.2.gz,
+// syslog.1 and syslog, and replaces each set with a single source that reads
+// the files one after another, oldest first. The set takes the place in the
+// list of its first file.
+func chainRotated(srcs Sources) Sources {
+	sets := make(map[string][]rotated)
+	setNames := make(map[*Source]string, len(srcs))
+	firsts := make(map[string]*Source)
+	for _, src := range srcs {
+		if src.stream {
+			continue
+		}
+		name, r := rotationOf(src)
+		if len(sets[name]) == 0 {
+			firsts[name] = src
+		}
+		sets[name] = append(sets[name], r)
+		setNames[src] = name
+	}
+
+	res := make(Sources, 0, len(srcs))
+	for _, src := range srcs {
+		name, ok := setNames[src]
+		if !ok || len(sets[name]) == 1 || !isRotation(sets[name]) {
+			res = append(res, src)
+			continue
+		}
+		set := sets[name]
+		if firsts[name] != src {
+			// Already chained, in place of the first file in the set
+			continue
+		}
+		sort.SliceStable(set, func(i, j int) bool {
+			return set[i].older(set[j])
+		})
+		parts := make([]*Source, 0, len(set))
+		for _, r := range set {
+			parts = append(parts, r.src)
+		}
+		res = append(res, newChainSource(name, parts))
+	}
+	return res
+}
+
+// newChainSource returns a source called name that reads each of parts in
+// turn. Only the last part can be followed; earlier parts being followed are
+// read to the end instead, since a follower never reaches the end.
+func newChainSource(name string, parts []*Source) *Source {
+	for i, part := range parts[:len(parts)-1] {
+		if part.follow != nil {
+			parts[i] = unfollow(part)
+		}
+	}
+	src := &Source{
+		Name:     name,
+		basename: filepath.Base(name),
+		Modified: parts[len(parts)-1].Modified,
+		follow:   parts[len(parts)-1].follow,
+	}
+	cr := &chainReader{parts: parts}
+	for _, part := range parts {
+		src.Parts = append(src.Parts, part.Name)
+	}
+	src.Reader = cr
+	src.closers = []io.Closer{cr}
+	for _, part := range parts {
+		if part.reopen == nil {
+			return src
+		}
+	}
+	src.reopen = func() (io.ReadCloser, error) {
+		fresh := make([]*Source, 0, len(parts))
+		for _, part := range parts {
+			lo := &lazyOpener{open: part.reopen}
+			fresh = append(fresh, &Source{Name: part.Name, Reader: lo, closers: []io.Closer{lo}})
+		}
+		return &chainReader{parts: fresh}, nil
+	}
+	return src
+}
+
+// unfollow returns a source that reads the file followed by src once, to the
+// end, in place of src, which is closed.
+func unfollow(src *Source) *Source {
+	src.Close()
+	name := src.Name
+	res := newLazySource(name, func() (io.ReadCloser, error) {
+		return os.Open(name)
+	}, nil)
+	res.Modified = src.Modified
+	return res
+}
+
+// chainReader reads several sources one after another. Each is closed once
+// it has been read to the end.
+type chainReader struct {
+	mu      sync.Mutex
+	parts   []*Source
+	partial bool // true if the last line read from the current part has no newline yet
+}
+
+func (c *chainReader) Read(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.parts) > 0 {
+		n, err := c.parts[0].Reader.Read(p)
+		if n > 0 {
+			c.partial = p[n-1] != '\n'
+			return n, nil
+		}
+		if err == nil {
+			continue
+		}
+		if err != io.EOF || len(c.parts) == 1 {
+			return 0, err
+		}
+		c.parts[0].Close()
+		c.parts = c.parts[1:]
+		if c.partial && len(p) > 0 {
+			// Don't let the last line of one file run into the first line of the next
+			c.partial = false
+			p[0] = '\n'
+			return 1, nil
+		}
+	}
+	return 0, io.EOF
+}
+
+func (c *chainReader) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Sources(c.parts).Close()
+}
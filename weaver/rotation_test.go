@@ -0,0 +1,118 @@
+package weaver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRotationOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		log    string
+		number int
+		date   string
+	}{
+		{"/var/log/syslog", "/var/log/syslog", -1, ""},
+		{"/var/log/syslog.1", "/var/log/syslog", 1, ""},
+		{"/var/log/syslog.2.gz", "/var/log/syslog", 2, ""},
+		{"/var/log/syslog.12.zst", "/var/log/syslog", 12, ""},
+		{"/var/log/messages-20201015", "/var/log/messages", 0, "20201015"},
+		{"/var/log/messages-2020101512.xz", "/var/log/messages", 0, "2020101512"},
+		{"bundle.tgz!/var/log/auth.log.3.gz", "bundle.tgz!/var/log/auth.log", 3, ""},
+		// A current log keeps its name, compressed or not
+		{"app.log.gz", "app.log.gz", -1, ""},
+		// Not a date that logrotate writes
+		{"/var/log/app-12345678", "/var/log/app-12345678", -1, ""},
+		// Addresses and versions, not rotations
+		{"ips/ss-10.0.0.1", "ips/ss-10.0.0.1", -1, ""},
+		{"lib-1.2.3", "lib-1.2.3", -1, ""},
+		{"syslog", "syslog", -1, ""},
+	}
+	for _, test := range tests {
+		src := &Source{Name: test.name}
+		log, r := rotationOf(src)
+		assert.Equal(t, test.log, log, test.name)
+		assert.Equal(t, test.number, r.number, test.name)
+		assert.Equal(t, test.date, r.date, test.name)
+		assert.Equal(t, src, r.src, test.name)
+	}
+}
+
+func TestRotatedOlder(t *testing.T) {
+	tests := []struct {
+		r, other rotated
+		want     bool
+	}{
+		{rotated{number: 2}, rotated{number: 1}, true},
+		{rotated{number: 1}, rotated{number: -1}, true},
+		{rotated{number: -1}, rotated{number: 1}, false},
+		{rotated{date: "20201014"}, rotated{date: "20201015"}, true},
+		{rotated{date: "20201015"}, rotated{date: "20201014"}, false},
+		{rotated{date: "20201015"}, rotated{number: -1}, true},
+		{rotated{number: 1}, rotated{date: "20201015"}, false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, test.r.older(test.other), "%+v older than %+v", test.r, test.other)
+	}
+}
+
+func TestChainRotated(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+		want  []string // the sources after chaining, with the parts of each chain
+	}{
+		{
+			name:  "current log and rotations",
+			files: []string{"log/syslog", "log/syslog.2.gz", "log/syslog.1", "log/auth.log"},
+			want:  []string{"log/syslog: log/syslog.2.gz log/syslog.1 log/syslog", "log/auth.log"},
+		},
+		{
+			name:  "rotations without the current log",
+			files: []string{"log/syslog.1", "log/syslog.2.gz"},
+			want:  []string{"log/syslog: log/syslog.2.gz log/syslog.1"},
+		},
+		{
+			name:  "dated rotations",
+			files: []string{"log/messages-20201015", "log/messages", "log/messages-20201014.gz"},
+			want:  []string{"log/messages: log/messages-20201014.gz log/messages-20201015 log/messages"},
+		},
+		{
+			name:  "addresses that look like rotations",
+			files: []string{"ips/ss-10.0.0.1", "ips/ss-10.0.0.2"},
+			want:  []string{"ips/ss-10.0.0.1", "ips/ss-10.0.0.2"},
+		},
+		{
+			name:  "numbers that don't start from 1",
+			files: []string{"out/run.7", "out/run.8"},
+			want:  []string{"out/run.7", "out/run.8"},
+		},
+		{
+			name:  "numbers with a gap",
+			files: []string{"log/app.log.1", "log/app.log.3"},
+			want:  []string{"log/app.log.1", "log/app.log.3"},
+		},
+	}
+	for _, test := range tests {
+		srcs := make(Sources, 0, len(test.files))
+		for _, name := range test.files {
+			src, err := NewSource(name, strings.NewReader(""))
+			if err != nil {
+				t.Fatal(err)
+			}
+			srcs = append(srcs, src)
+		}
+		var got []string
+		for _, src := range chainRotated(srcs) {
+			if len(src.Parts) == 0 {
+				got = append(got, src.Name)
+			} else {
+				got = append(got, src.Name+": "+strings.Join(src.Parts, " "))
+			}
+		}
+		assert.Equal(t, test.want, got, test.name)
+		srcs.Close()
+	}
+}
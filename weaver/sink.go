@@ -0,0 +1,248 @@
+package weaver
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lestrrat-go/strftime"
+	"github.com/logrusorgru/aurora"
+)
+
+// Sink renders the records produced by a Merger.
+type Sink interface {
+	// Begin is called once, before any records, with every source to be merged.
+	Begin(srcs []*Source) error
+	// Write is called for each record, in chronological order.
+	Write(rec *Record) error
+}
+
+// Weave reads every record from m, in order, and writes it to sink.
+func Weave(m *Merger, sink Sink) error {
+	defer m.Close()
+	// The first lines of the sources determine e.g. the timezone assumed for each
+	m.begin()
+	if err := sink.Begin(m.Sources()); err != nil {
+		return err
+	}
+	for {
+		rec, err := m.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := sink.Write(rec); err != nil {
+			return err
+		}
+	}
+}
+
+// TextSinkOptions control the layout of a TextSink's output.
+type TextSinkOptions struct {
+	UseFullname       bool               // use the full name of each source, not its basename
+	FilenameEveryLine bool               // show the source name on every line, not just when it changes
+	TimeFormat        *strftime.Strftime // for the timestamp prefix
+	Location          *time.Location     // timestamps are displayed relative to this timezone
+	NoTimestamp       bool               // don't prefix each line with the normalized timestamp
+	TailStyle         bool               // tail -F style output - source name on a separate line, no prefix
+	AltStyle          bool               // source name on a separate line; timestamp is a prefix
+	Separator         bool               // print a separator line when the source changes
+	Colors            int                // the number of terminal colors to use; 0 for none
+}
+
+// TextSink writes records as human-readable lines, in the style of the
+// logweaver command.
+type TextSink struct {
+	w            io.Writer
+	opts         TextSinkOptions
+	prefixFormat string
+	separator    string
+	colors       map[*Source]uint8 // the color for each source, once included
+	nextColor    int               // the next color to hand out
+	lastFile     string            // keep track of the last file that generated output, so we know if we need a separator
+	lineArgs     []interface{}
+}
+
+var _ Sink = (*TextSink)(nil)
+
+// NewTextSink returns a TextSink that writes to w.
+func NewTextSink(w io.Writer, opts TextSinkOptions) *TextSink {
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	if opts.TimeFormat == nil {
+		opts.TimeFormat, _ = strftime.New("%a %T")
+	}
+	return &TextSink{
+		w:        w,
+		opts:     opts,
+		colors:   make(map[*Source]uint8),
+		lineArgs: make([]interface{}, 0, 8),
+	}
+}
+
+// Begin lists the sources to be merged, and computes the width of the source
+// name column.
+func (t *TextSink) Begin(srcs []*Source) error {
+	longestLen := -1
+	for _, src := range srcs {
+		if len(t.name(src)) > longestLen {
+			longestLen = len(t.name(src))
+		}
+	}
+
+	var timeLen int
+	if t.opts.TimeFormat != nil {
+		timeLen = len(t.opts.TimeFormat.FormatString(time.Now()))
+	}
+
+	switch {
+	case t.opts.NoTimestamp && t.opts.AltStyle:
+		// "%s"
+		t.prefixFormat = "%s\n"
+		// ========================
+		t.separator = fmt.Sprintf(t.prefixFormat,
+			strings.Repeat("=", 40),
+		)
+	case t.opts.NoTimestamp && !t.opts.AltStyle:
+		// "%s | %-20s | %s
+		t.prefixFormat = fmt.Sprintf("%%-%ds", longestLen) + " | %s\n"
+		// ==================== | ========================
+		t.separator = fmt.Sprintf(t.prefixFormat,
+			strings.Repeat("=", longestLen),
+			strings.Repeat("=", 40),
+		)
+	case !t.opts.NoTimestamp && t.opts.AltStyle:
+		// "%s | %s
+		t.prefixFormat = "%s | %s\n"
+		// ======== | ==================== | ========================
+		t.separator = fmt.Sprintf(t.prefixFormat,
+			strings.Repeat("=", timeLen),
+			strings.Repeat("=", 40),
+		)
+	case !t.opts.NoTimestamp && !t.opts.AltStyle:
+		// "%s | %-20s | %s
+		t.prefixFormat = "%s | " + fmt.Sprintf("%%-%ds", longestLen) + " | %s\n"
+		// ======== | ==================== | ========================
+		t.separator = fmt.Sprintf(t.prefixFormat,
+			strings.Repeat("=", timeLen),
+			strings.Repeat("=", longestLen),
+			strings.Repeat("=", 40),
+		)
+	}
+
+	for _, src := range srcs {
+		if err := t.include(src); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(t.w)
+	return err
+}
+
+// include assigns a color to src, and announces it.
+func (t *TextSink) include(src *Source) error {
+	if t.nextColor == 0 {
+		if t.opts.Colors > 8 {
+			t.nextColor = 9 // skip dark colors
+		} else {
+			t.nextColor = 1 // skip black
+		}
+	} else if t.nextColor == 7 {
+		t.nextColor = 1
+	}
+	if t.opts.Colors > 0 {
+		t.colors[src] = uint8(t.nextColor % t.opts.Colors)
+	} else {
+		t.colors[src] = 0
+	}
+	t.nextColor += 1
+	line := fmt.Sprintf("Including file %s", src.Name)
+	if len(src.Parts) > 1 {
+		parts := make([]string, 0, len(src.Parts))
+		for _, part := range src.Parts {
+			parts = append(parts, filepath.Base(part))
+		}
+		line += fmt.Sprintf(" (rotated: %s)", strings.Join(parts, ", "))
+	}
+	if src.Label != "" {
+		line += fmt.Sprintf(" as %s", src.Label)
+	}
+	if src.zone != nil {
+		line += fmt.Sprintf(" (timezone %s)", src.zone)
+	}
+	if src.fromBoot && !src.Booted.IsZero() {
+		line += fmt.Sprintf(" (booted %s)", src.Booted.UTC().Format(time.RFC3339))
+	}
+	return t.print(src, line+"\n")
+}
+
+// Write renders a single record. A source not passed to Begin - e.g. one
+// found while following - is announced first.
+func (t *TextSink) Write(rec *Record) error {
+	if _, ok := t.colors[rec.Source]; !ok {
+		if err := t.include(rec.Source); err != nil {
+			return err
+		}
+	}
+	var logFileArg string
+	if (rec.Continuation || t.lastFile == rec.Source.Name) && !t.opts.FilenameEveryLine {
+		logFileArg = ""
+	} else {
+		logFileArg = t.name(rec.Source)
+	}
+	if !t.opts.TailStyle && t.opts.Separator && t.lastFile != "" && t.lastFile != rec.Source.Name {
+		// A separator not a header, so don't emit for the first file
+		t.print(rec.Source, t.separator)
+	}
+	if t.opts.TailStyle || t.opts.AltStyle {
+		// More of a header than separator, so print out for the first file
+		if t.lastFile != rec.Source.Name {
+			t.print(rec.Source, fmt.Sprintf("\n==> %s <==\n", logFileArg))
+		}
+	}
+
+	var err error
+	if t.opts.TailStyle {
+		err = t.print(rec.Source, rec.Text+"\n")
+	} else {
+		// The lines of a multi-line record after the first are shown like continuations
+		for i, text := range strings.Split(rec.Text, "\n") {
+			if i > 0 && !t.opts.FilenameEveryLine {
+				logFileArg = ""
+			}
+			t.lineArgs = t.lineArgs[:0]
+			if !t.opts.NoTimestamp {
+				t.lineArgs = append(t.lineArgs, t.opts.TimeFormat.FormatString(rec.Time.In(t.opts.Location)))
+			}
+			if !t.opts.AltStyle {
+				t.lineArgs = append(t.lineArgs, logFileArg)
+			}
+			t.lineArgs = append(t.lineArgs, text)
+			if err = t.print(rec.Source, fmt.Sprintf(t.prefixFormat, t.lineArgs...)); err != nil {
+				break
+			}
+		}
+	}
+
+	t.lastFile = rec.Source.Name
+	return err
+}
+
+func (t *TextSink) name(src *Source) string {
+	return src.DisplayName(t.opts.UseFullname)
+}
+
+func (t *TextSink) print(src *Source, s string) error {
+	var err error
+	if t.opts.Colors > 0 {
+		_, err = fmt.Fprintf(t.w, "%s", aurora.Index(t.colors[src], s))
+	} else {
+		_, err = io.WriteString(t.w, s)
+	}
+	return err
+}
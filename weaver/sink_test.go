@@ -0,0 +1,60 @@
+package weaver
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// failingWriter fails to write anything containing fail.
+type failingWriter struct {
+	fail string
+}
+
+func (f failingWriter) Write(p []byte) (int, error) {
+	if strings.Contains(string(p), f.fail) {
+		return 0, errors.New("write failed")
+	}
+	return len(p), nil
+}
+
+func TestTextSinkBeginError(t *testing.T) {
+	src, err := NewSource("app.log", strings.NewReader(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+
+	sink := NewTextSink(failingWriter{fail: "Including file"}, TextSinkOptions{})
+	assert.EqualError(t, sink.Begin([]*Source{src}), "write failed")
+}
+
+func TestTextSinkAnnouncesTimezone(t *testing.T) {
+	conf, err := DecodeConfig(strings.NewReader(`
+[[match]]
+match = '^(\d{4}-\d\d-\d\d \d\d:\d\d:\d\d) '
+format = '2006-01-02 15:04:05'
+timezone = 'Europe/Berlin'
+files = ['berlin.log']
+` + testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var srcs Sources
+	for _, name := range []string{"berlin.log", "utc.log"} {
+		src, err := NewSource(name, strings.NewReader("2020-10-05 16:00:00 started\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		srcs = append(srcs, src)
+	}
+	defer srcs.Close()
+
+	var b strings.Builder
+	if assert.NoError(t, Weave(NewMerger(conf, srcs, Options{}), NewTextSink(&b, TextSinkOptions{}))) {
+		assert.Contains(t, b.String(), "Including file berlin.log (timezone Europe/Berlin)\n")
+		assert.Contains(t, b.String(), "Including file utc.log\n")
+	}
+}
@@ -0,0 +1,483 @@
+package weaver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// StdinName is the name that refers to standard input, rather than a file.
+const StdinName = "-"
+
+// Source is a single log stream to be merged, e.g. one log file.
+type Source struct {
+	Name          string                        // e.g. /var/log/keepalived.log
+	Label         string                        // if not empty, shown instead of the name e.g. for stdin
+	Parts         []string                      // if the log has been rotated into several files, their names, oldest first
+	Reader        io.Reader                     // the log contents, decompressed
+	Offset        time.Duration                 // added to every timestamp read from this source
+	Location      *time.Location                // if not nil, the timezone of timestamps that don't include one, overriding the config
+	Modified      time.Time                     // when the log was last written, if known; the year of timestamps that don't include one is inferred from it
+	Booted        time.Time                     // when the system that wrote the log booted, if known; timestamps counted from boot are relative to it
+	ReorderWindow time.Duration                 // if set, overrides the rule's Match.ReorderWindow
+	ReorderLines  int                           // if set, overrides the rule's Match.ReorderLines
+	basename      string                        // e.g. keepalived.log (compute once)
+	follow        follower                      // if not nil, the source is being followed as it grows
+	stream        bool                          // true if the source is a pipe or similar, not a file
+	zone          *time.Location                // the timezone assumed for the source, once its rule is known, if one was set
+	fromBoot      bool                          // true if the source's timestamps count from boot, once its rule is known
+	reopen        func() (io.ReadCloser, error) // if not nil, opens the source again from the start, decompressed
+	closers       []io.Closer
+}
+
+// OpenOptions control how log files are opened.
+type OpenOptions struct {
+	Warnings     io.Writer     // if not nil, told about files inside directories that can't be opened
+	Follow       bool          // keep reading uncompressed files as they grow, like tail -F
+	PollInterval time.Duration // when following, how often to check files for new data
+
+	SeparateRotated bool // don't chain rotated logs e.g. syslog.2.gz, syslog.1, syslog into one source
+}
+
+// NewSource returns a Source called name that reads from r. If r is
+// compressed in a recognised format, e.g. gzip or zstd, it is transparently
+// decompressed.
+func NewSource(name string, r io.Reader) (*Source, error) {
+	src := &Source{
+		Name:     name,
+		basename: filepath.Base(name),
+	}
+
+	reader, closer, err := decompress(name, r)
+	if err != nil {
+		return nil, err
+	}
+	src.Reader = reader
+	if closer != nil {
+		src.closers = append(src.closers, closer)
+	}
+
+	return src, nil
+}
+
+// lazyReader decompresses a stream, but only decides how on the first read.
+// This means opening a pipe doesn't wait for the writer to produce anything.
+type lazyReader struct {
+	name   string
+	r      io.Reader
+	dec    io.Reader
+	closer io.Closer
+}
+
+func (l *lazyReader) Read(p []byte) (int, error) {
+	if l.dec == nil {
+		dec, closer, err := decompress(l.name, l.r)
+		if err != nil {
+			return 0, err
+		}
+		l.dec = dec
+		l.closer = closer
+	}
+	return l.dec.Read(p)
+}
+
+func (l *lazyReader) Close() error {
+	if l.closer == nil {
+		return nil
+	}
+	return l.closer.Close()
+}
+
+// lazyOpener calls open on the first read. This means many sources - e.g.
+// rotated files to be read one after another - don't all hold a file open.
+type lazyOpener struct {
+	open func() (io.ReadCloser, error)
+	r    io.ReadCloser
+}
+
+func (l *lazyOpener) Read(p []byte) (int, error) {
+	if l.r == nil {
+		r, err := l.open()
+		if err != nil {
+			return 0, err
+		}
+		l.r = r
+	}
+	return l.r.Read(p)
+}
+
+func (l *lazyOpener) Close() error {
+	if l.r == nil {
+		return nil
+	}
+	return l.r.Close()
+}
+
+// newLazySource returns a source that isn't opened until it is first read,
+// and is decompressed if necessary. ref, if not nil, is closed along with the
+// source.
+func newLazySource(name string, open func() (io.ReadCloser, error), ref io.Closer) *Source {
+	lo := &lazyOpener{open: open}
+	lr := &lazyReader{name: name, r: lo}
+	src := &Source{
+		Name:     name,
+		Reader:   lr,
+		basename: filepath.Base(name),
+		closers:  []io.Closer{lr, lo},
+	}
+	if ref != nil {
+		src.closers = append(src.closers, ref)
+	}
+	src.reopen = func() (io.ReadCloser, error) {
+		r, err := open()
+		if err != nil {
+			return nil, err
+		}
+		dec, closer, err := decompress(name, r)
+		if err != nil {
+			r.Close()
+			return nil, err
+		}
+		closers := multiCloser{r}
+		if closer != nil {
+			closers = append(closers, closer)
+		}
+		return struct {
+			io.Reader
+			io.Closer
+		}{dec, closers}, nil
+	}
+	return src
+}
+
+// OpenSource opens the log file called name, which may be StdinName, or a
+// named pipe such as /dev/fd/63 from a shell's process substitution.
+func OpenSource(name string, opts OpenOptions) (*Source, error) {
+	file, err := openFile(name)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	src, err := newFileSource(name, file, fi, opts)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return src, nil
+}
+
+func openFile(name string) (*os.File, error) {
+	if name == StdinName {
+		return os.Stdin, nil
+	}
+	return os.Open(name)
+}
+
+// newFileSource returns a source that reads from file. It takes ownership of
+// the file unless there is an error.
+func newFileSource(name string, file *os.File, fi os.FileInfo, opts OpenOptions) (*Source, error) {
+	if !fi.Mode().IsRegular() || name == StdinName {
+		// Standard input is read once, even if redirected from a file, since
+		// there's no name to open it again by.
+		return newStreamSource(name, file, opts), nil
+	}
+	if opts.Follow {
+		// Compressed files can't be followed - they're just read to the end.
+		magic := make([]byte, maxMagicLen())
+		if n, _ := file.ReadAt(magic, 0); sniff(magic[:n]) == nil {
+			fr := newFollowReader(name, file, fi, opts.PollInterval)
+			return &Source{
+				Name:     name,
+				Reader:   bufio.NewReaderSize(fr, 65536*8),
+				Modified: fi.ModTime(),
+				basename: filepath.Base(name),
+				follow:   fr,
+				closers:  []io.Closer{fr},
+				reopen: func() (io.ReadCloser, error) {
+					// Just what the file held when it was opened
+					file, err := os.Open(name)
+					if err != nil {
+						return nil, err
+					}
+					return struct {
+						io.Reader
+						io.Closer
+					}{io.LimitReader(file, fi.Size()), file}, nil
+				},
+			}, nil
+		}
+	}
+	// The file is opened again when it's first read
+	file.Close()
+	src := newLazySource(name, func() (io.ReadCloser, error) {
+		return os.Open(name)
+	}, nil)
+	src.Modified = fi.ModTime()
+	return src, nil
+}
+
+// newStreamSource returns a source that reads from a pipe, terminal or
+// socket, which can only be read once, from the start. It takes ownership of
+// file.
+func newStreamSource(name string, file *os.File, opts OpenOptions) *Source {
+	src := &Source{
+		Name:     name,
+		basename: filepath.Base(name),
+		stream:   true,
+	}
+	if name == StdinName {
+		src.Label = "<stdin>"
+	}
+
+	var r io.Reader = file
+	if opts.Follow {
+		fp := newFollowPipe(file)
+		src.follow = fp
+		src.closers = append(src.closers, fp)
+		r = fp
+	}
+	lr := &lazyReader{name: name, r: r}
+	src.Reader = lr
+	src.closers = append(src.closers, lr, file)
+	return src
+}
+
+// DisplayName returns the label of the source, if it has one. Otherwise it
+// returns the source's name - the full name if full is true, or else just the
+// last element.
+func (s *Source) DisplayName(full bool) string {
+	switch {
+	case s.Label != "":
+		return s.Label
+	case full:
+		return s.Name
+	default:
+		return s.basename
+	}
+}
+
+// Basename returns the last element of the source's name.
+func (s *Source) Basename() string {
+	return s.basename
+}
+
+// MatchesGlob returns true if pattern, a glob as understood by filepath.Match,
+// matches either the name of the source or its last element.
+func (s *Source) MatchesGlob(pattern string) bool {
+	if ok, _ := filepath.Match(pattern, s.Name); ok {
+		return true
+	}
+	ok, _ := filepath.Match(pattern, s.basename)
+	return ok
+}
+
+// matchesAnyGlob returns true if any of patterns matches the source, as for
+// MatchesGlob.
+func (s *Source) matchesAnyGlob(patterns []string) bool {
+	for _, pattern := range patterns {
+		if s.MatchesGlob(pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// location returns the timezone of timestamps that don't include one, if rule
+// is used for the source - or nil for UTC.
+func (s *Source) location(rule *Match) *time.Location {
+	if s.Location != nil {
+		return s.Location
+	}
+	return rule.loc
+}
+
+// Close releases any files and decompressors held by the source.
+func (s *Source) Close() error {
+	var err error
+	for _, c := range s.closers {
+		err2 := c.Close()
+		if err2 != nil {
+			err = err2
+		}
+	}
+	s.closers = nil
+	return err
+}
+
+// Sources is a list of sources, typically built from command-line arguments.
+type Sources []*Source
+
+// Close closes every source in the list.
+func (s Sources) Close() error {
+	var err error
+	for _, src := range s {
+		err2 := src.Close()
+		if err2 != nil {
+			err = err2
+		}
+	}
+	return err
+}
+
+// We'll keep a list of these to open, computed from command line arguments
+type logFileArg struct {
+	name        string
+	notRequired bool
+}
+
+// OpenSources opens each of the named files, in order. Directories are read
+// recursively, and so are tar and zip archives, whose members are named e.g.
+// bundle.tgz!/var/log/syslog. Files found inside a directory are not required, so if one of
+// them can't be opened, or read as an archive, a warning is written to opts.Warnings and it is
+// skipped.
+func OpenSources(names []string, opts OpenOptions) (Sources, error) {
+	return openSources(names, nil, opts)
+}
+
+// RescanSources looks inside each of the named directories again, and opens
+// any files that aren't already in known - e.g. log files created since
+// known was returned by OpenSources. Files that were followed by a source in
+// known, then rotated away to a new name, are not opened again.
+func RescanSources(names []string, known []*Source, opts OpenOptions) (Sources, error) {
+	skip := make(map[string]bool, len(known))
+	for _, src := range known {
+		skip[src.Name] = true
+		for _, part := range src.Parts {
+			skip[part] = true
+		}
+	}
+	srcs, err := openSources(names, skip, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	res := srcs[:0]
+	for _, src := range srcs {
+		if fr, ok := src.follow.(*followReader); ok && followedBy(fr.seen[0], known) {
+			src.Close()
+			continue
+		}
+		res = append(res, src)
+	}
+	return res, nil
+}
+
+func followedBy(fi os.FileInfo, known []*Source) bool {
+	for _, src := range known {
+		if fr, ok := src.follow.(*followReader); ok && fr.hasFollowed(fi) {
+			return true
+		}
+	}
+	return false
+}
+
+// openSources opens the named files, walking directories. Files in skip are
+// ignored, whether named directly or found in a directory.
+func openSources(names []string, skip map[string]bool, opts OpenOptions) (Sources, error) {
+	// Overall approach is to start with pending, which might contain directories, then
+	// flatten as we transfer to res.
+	pending := make([]logFileArg, 0, 128)
+	res := make(Sources, 0, 128)
+
+	// build up pending list from CLI args
+	stdin := false
+	for _, name := range names {
+		if skip[name] {
+			continue
+		}
+		if name == StdinName {
+			if stdin {
+				return nil, fmt.Errorf("standard input can only be read once")
+			}
+			stdin = true
+		}
+		pending = append(pending, logFileArg{name: name}) // all are required
+	}
+
+	fail := func(err error) (Sources, error) {
+		res.Close()
+		return nil, err
+	}
+
+	// Process these, in FIFO order. Each file gets moved to res. Each dir is
+	// walked recursively, adding to the end of pending, growing it. When
+	// finished, res will be a (maybe long) list of all log files to process.
+	for len(pending) > 0 {
+		cur := pending[0]
+		pending = pending[1:]
+
+		handle, err := openFile(cur.name)
+		if err != nil {
+			if !cur.notRequired {
+				return fail(fmt.Errorf("error opening log file %s: %w", cur.name, err))
+			}
+			if opts.Warnings != nil {
+				fmt.Fprintf(opts.Warnings, "Warning, problem issuing stat on %s: %v\n", cur.name, err)
+			}
+			continue
+		}
+		fi, err := handle.Stat()
+		switch {
+		case err != nil:
+			handle.Close()
+			return fail(fmt.Errorf("could not stat file %s: %w", cur.name, err))
+		case fi.IsDir():
+			handle.Close()
+			err = filepath.Walk(cur.name, func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				if !info.IsDir() && !skip[path] {
+					pending = append(pending, logFileArg{
+						name:        path,
+						notRequired: true,
+					})
+				}
+				return nil
+			})
+			if err != nil {
+				return fail(fmt.Errorf("error scanning directory %s: %w", cur.name, err))
+			}
+		default:
+			if fi.Mode().IsRegular() {
+				members, ok, err := openArchive(cur.name, handle, fi)
+				if err != nil {
+					if !ok {
+						handle.Close()
+					}
+					if !cur.notRequired {
+						return fail(err)
+					}
+					if opts.Warnings != nil {
+						fmt.Fprintf(opts.Warnings, "Warning, skipping %s: %v\n", cur.name, err)
+					}
+					continue
+				}
+				if ok {
+					// An archive is read like a directory
+					res = append(res, members...)
+					continue
+				}
+			}
+			src, err := newFileSource(cur.name, handle, fi, opts)
+			if err != nil {
+				handle.Close()
+				return fail(err)
+			}
+			res = append(res, src)
+		}
+	}
+
+	if !opts.SeparateRotated {
+		res = chainRotated(res)
+	}
+
+	return res, nil
+}
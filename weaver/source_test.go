@@ -0,0 +1,142 @@
+package weaver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamSourceCloseStopsFollow(t *testing.T) {
+	for _, pending := range []bool{false, true} {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		before := runtime.NumGoroutine()
+		src := newStreamSource("pipe", r, OpenOptions{Follow: true})
+		if pending {
+			// Fill the pipe's channel, then give its goroutine more to hand over
+			fp := src.follow.(*followPipe)
+			w.Write([]byte("first\n"))
+			for len(fp.chunks) == 0 {
+				time.Sleep(time.Millisecond)
+			}
+			w.Write([]byte("second\n"))
+			time.Sleep(100 * time.Millisecond)
+		}
+		src.Close()
+
+		deadline := time.Now().Add(5 * time.Second)
+		for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if runtime.NumGoroutine() > before {
+			t.Errorf("pending data %v: the pipe's goroutine is still running after Close", pending)
+		}
+		w.Close()
+	}
+}
+
+// withStdin runs f with standard input reading from file.
+func withStdin(file *os.File, f func()) {
+	stdin := os.Stdin
+	os.Stdin = file
+	defer func() { os.Stdin = stdin }()
+	f()
+}
+
+func TestStdinSource(t *testing.T) {
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte("compressed\n"))
+	zw.Close()
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"plain", []byte("one\ntwo\n"), "one\ntwo\n"},
+		{"compressed", gz.Bytes(), "compressed\n"},
+	}
+	for _, test := range tests {
+		for _, redirected := range []bool{false, true} {
+			var file *os.File
+			if redirected {
+				// Redirected from a regular file, but still read just the once
+				tmp, err := ioutil.TempFile("", "logweaver-test")
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer os.Remove(tmp.Name())
+				tmp.Write(test.data)
+				tmp.Seek(0, io.SeekStart)
+				file = tmp
+			} else {
+				r, w, err := os.Pipe()
+				if err != nil {
+					t.Fatal(err)
+				}
+				go func(data []byte) {
+					w.Write(data)
+					w.Close()
+				}(test.data)
+				file = r
+			}
+
+			withStdin(file, func() {
+				src, err := OpenSource(StdinName, OpenOptions{})
+				if !assert.NoError(t, err, test.name) {
+					return
+				}
+				defer src.Close()
+				assert.True(t, src.stream, test.name)
+				assert.Equal(t, "<stdin>", src.DisplayName(true), test.name)
+				data, err := ioutil.ReadAll(src.Reader)
+				if assert.NoError(t, err, test.name) {
+					assert.Equal(t, test.want, string(data), "%s, redirected %v", test.name, redirected)
+				}
+			})
+		}
+	}
+}
+
+func TestFollowPipe(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := newStreamSource("pipe", r, OpenOptions{Follow: true})
+	defer src.Close()
+	fp := src.follow.(*followPipe)
+
+	// With nothing written yet, the reader stops if told to by idle
+	fp.setIdle(func() bool { return false })
+	buf := make([]byte, 64)
+	n, err := fp.Read(buf)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, io.EOF, err)
+
+	// ...and otherwise waits for the stream
+	fp.setIdle(nil)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("one\n"))
+		w.Close()
+	}()
+	n, err = fp.Read(buf)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "one\n", string(buf[:n]))
+	}
+
+	// The end of the stream is the end of the source
+	_, err = fp.Read(buf)
+	assert.Equal(t, io.EOF, err)
+}
@@ -0,0 +1,92 @@
+package weaver
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// strptimeLayouts maps strptime(3) conversions to Go's reference-time layout
+// elements - see https://golang.org/pkg/time/#pkg-constants
+var strptimeLayouts = map[string]string{
+	"Y":  "2006",
+	"y":  "06",
+	"m":  "01",
+	"d":  "02",
+	"e":  "_2",
+	"j":  "002",
+	"b":  "Jan",
+	"h":  "Jan",
+	"B":  "January",
+	"a":  "Mon",
+	"A":  "Monday",
+	"H":  "15",
+	"I":  "03",
+	"M":  "04",
+	"S":  "05",
+	"p":  "PM",
+	"Z":  "MST",
+	"z":  "-0700",
+	":z": "-07:00",
+	"T":  "15:04:05",
+	"R":  "15:04",
+	"D":  "01/02/06",
+	"F":  "2006-01-02",
+	"%":  "%",
+}
+
+// isStrptime returns true if format is in the style of strptime(3), e.g.
+// %Y-%m-%d %H:%M:%S, rather than a Go layout.
+func isStrptime(format string) bool {
+	return strings.Contains(format, "%")
+}
+
+// literalProbe is a time that formats differently from every element of a Go
+// layout - it's in the morning, on a Tuesday, in zone XYZ.
+var literalProbe = time.Date(1999, time.November, 23, 1, 44, 55, 123456789, time.FixedZone("XYZ", 5*3600+1800))
+
+// strptimeToLayout converts a strptime(3)-style format to a Go layout. %f is
+// a fraction of a second, of any number of digits, and must follow a . or ,
+// Literal text that Go would read as part of the time, like the 1 in
+// %H:%M:%S 1, is an error, since a Go layout can't escape it.
+func strptimeToLayout(format string) (string, error) {
+	var b strings.Builder
+	literal := 0 // the start of the literal text before format[i]
+	for i := 0; i <= len(format); i++ {
+		if i < len(format) && format[i] != '%' {
+			b.WriteByte(format[i])
+			continue
+		}
+		if lit := format[literal:i]; literalProbe.Format(lit) != lit {
+			return "", fmt.Errorf("format %s has literal text %q that would be read as part of the time", format, lit)
+		}
+		if i == len(format) {
+			break
+		}
+		if i+1 >= len(format) {
+			return "", fmt.Errorf("format %s ends with %%", format)
+		}
+		conv := format[i+1 : i+2]
+		if conv == ":" && i+2 < len(format) {
+			conv = format[i+1 : i+3]
+		}
+		i += len(conv)
+		literal = i + 1
+		switch conv {
+		case "f":
+			if i < 2 || (format[i-2] != '.' && format[i-2] != ',') {
+				return "", fmt.Errorf("%%f must follow . or , in format %s", format)
+			}
+			b.WriteString("999999999")
+		case "s":
+			return "", fmt.Errorf("%%s is not supported in format %s - use unit = 's' instead", format)
+		default:
+			layout, ok := strptimeLayouts[conv]
+			if !ok {
+				return "", fmt.Errorf("unsupported conversion %%%s in format %s", conv, format)
+			}
+			b.WriteString(layout)
+		}
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,55 @@
+package weaver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrptimeToLayout(t *testing.T) {
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"%Y-%m-%d %H:%M:%S", "2006-01-02 15:04:05"},
+		{"%Y-%m-%d %H:%M:%S.%f", "2006-01-02 15:04:05.999999999"},
+		{"%Y-%m-%d %H:%M:%S,%f", "2006-01-02 15:04:05,999999999"},
+		{"%d/%b/%Y:%T %z", "02/Jan/2006:15:04:05 -0700"},
+		{"%FT%T%:z", "2006-01-02T15:04:05-07:00"},
+		{"%b %e %T", "Jan _2 15:04:05"},
+		{"%a %B %d %I:%M %p %Z", "Mon January 02 03:04 PM MST"},
+		{"%D %R", "01/02/06 15:04"},
+		{"%% done %y", "% done 06"},
+		{"[%d/%b/%Y] T%T UTC", "[02/Jan/2006] T15:04:05 UTC"},
+		{"no conversions", "no conversions"},
+	}
+	for _, test := range tests {
+		got, err := strptimeToLayout(test.format)
+		if assert.NoError(t, err, test.format) {
+			assert.Equal(t, test.want, got, test.format)
+		}
+	}
+}
+
+func TestStrptimeToLayoutErrors(t *testing.T) {
+	tests := []string{
+		"%Y-%m-%d %",
+		"%H:%M:%S%f",
+		"%s",
+		"%Q",
+		// Literal text that Go would read as part of the time
+		"%H:%M:%S 1",
+		"100%% done %y",
+		"%Y-%m-%d 04:%S",
+		"Mon %b %e %T",
+		"%T PM",
+		"%T MST",
+		"in 2006 at %T",
+		"%T.000",
+		"%T-07",
+	}
+	for _, format := range tests {
+		_, err := strptimeToLayout(format)
+		assert.Error(t, err, format)
+	}
+}
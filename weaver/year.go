@@ -0,0 +1,76 @@
+package weaver
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// A log's last line can be a little after the file's modification time, e.g.
+// if its timestamps are local time but are parsed as UTC.
+const yearSlack = 24 * time.Hour
+
+// Half a year - a timestamp without a year is taken to be within this of a
+// neighbouring log's timestamps.
+const halfYear = 183 * 24 * time.Hour
+
+// Modification times before this are taken to be unknown e.g. the DOS epoch
+// of a zip member stored without one, or the Unix epoch of a reproducible tar.
+var earliestModified = time.Date(1981, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// yearHint is what's known about when a log was written, for inferring the
+// year of timestamps that don't include one, like 'Jan 2 15:04:05'.
+type yearHint struct {
+	tm  time.Time // a time the log's lines are close to
+	end bool      // true if tm is when the log was last written, so no line should be later
+}
+
+// newYearHint returns a hint for src from when it was last written, or else
+// the current time.
+func newYearHint(src *Source) yearHint {
+	if src.Modified.After(earliestModified) {
+		return yearHint{tm: src.Modified, end: true}
+	}
+	return yearHint{tm: time.Now(), end: true}
+}
+
+// year returns the year of tm, a timestamp without one.
+func (h yearHint) year(tm time.Time) int {
+	year := h.tm.Year()
+	t := tm.AddDate(year, 0, 0)
+	switch {
+	case h.end && t.After(h.tm.Add(yearSlack)):
+		year--
+	case !h.end && t.Sub(h.tm) > halfYear:
+		year--
+	case !h.end && h.tm.Sub(t) > halfYear:
+		year++
+	}
+	return year
+}
+
+// bundleOf returns the name of the archive or directory holding the log
+// called name, whose other logs are its neighbours.
+func bundleOf(name string) string {
+	if i := strings.Index(name, ArchiveSeparator); i != -1 {
+		return name[:i]
+	}
+	return filepath.Dir(name)
+}
+
+// inferYear returns tm, a timestamp from the parser's source, with the year
+// filled in if it doesn't include one. The first such timestamp takes its
+// year from the parser's hint, and later ones follow on from it, moving to
+// the next year when the month goes backwards e.g. from December to January.
+func (p *parser) inferYear(tm time.Time) time.Time {
+	if tm.Year() != 0 {
+		return tm
+	}
+	if p.year == 0 {
+		p.year = p.yearHint.year(tm)
+	} else if tm.Month() < p.month-6 {
+		p.year++
+	}
+	p.month = tm.Month()
+	return tm.AddDate(p.year, 0, 0)
+}
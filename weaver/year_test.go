@@ -0,0 +1,50 @@
+package weaver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestYearHint(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 12, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name string
+		hint yearHint
+		tm   time.Time // without a year
+		want int
+	}{
+		{"before the end", yearHint{tm: date(2020, time.October, 15), end: true}, date(0, time.September, 26), 2020},
+		{"just after the end", yearHint{tm: date(2020, time.October, 15), end: true}, date(0, time.October, 15).Add(time.Hour), 2020},
+		{"after the end", yearHint{tm: date(2020, time.January, 5), end: true}, date(0, time.December, 30), 2019},
+		{"near a neighbour", yearHint{tm: date(2020, time.October, 15)}, date(0, time.November, 20), 2020},
+		{"months after a neighbour", yearHint{tm: date(2020, time.January, 5)}, date(0, time.December, 30), 2019},
+		{"months before a neighbour", yearHint{tm: date(2020, time.December, 30)}, date(0, time.January, 5), 2021},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, test.hint.year(test.tm), test.name)
+	}
+}
+
+func TestInferYear(t *testing.T) {
+	src := &Source{Name: "syslog", Modified: time.Date(2021, time.January, 2, 0, 0, 0, 0, time.UTC)}
+	p := newParser(src, nil, nil, &Options{})
+	for _, test := range []struct {
+		month time.Month
+		want  int
+	}{
+		{time.December, 2020},
+		{time.December, 2020},
+		{time.January, 2021},
+		{time.January, 2021},
+	} {
+		tm := p.inferYear(time.Date(0, test.month, 1, 0, 0, 0, 0, time.UTC))
+		assert.Equal(t, test.want, tm.Year(), test.month.String())
+	}
+
+	withYear := time.Date(2015, time.March, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, withYear, p.inferYear(withYear))
+}
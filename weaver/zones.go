@@ -0,0 +1,112 @@
+package weaver
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// defaultAbbreviations are the offsets, in seconds east of UTC, of timezone
+// abbreviations commonly seen in logs. Go's time.Parse only knows the offset
+// of an abbreviation if it belongs to the local timezone, and otherwise gives
+// it an offset of zero. Some abbreviations are ambiguous - e.g. IST is used
+// for India, Ireland and Israel - so a config can override these.
+var defaultAbbreviations = map[string]int{
+	"UTC":  0,
+	"UT":   0,
+	"GMT":  0,
+	"Z":    0,
+	"WET":  0,
+	"WEST": 1 * 3600,
+	"BST":  1 * 3600,
+	"IST":  5*3600 + 1800, // India - also Ireland (+01:00) and Israel (+02:00)
+	"CET":  1 * 3600,
+	"CEST": 2 * 3600,
+	"MET":  1 * 3600,
+	"MEST": 2 * 3600,
+	"EET":  2 * 3600,
+	"EEST": 3 * 3600,
+	"MSK":  3 * 3600,
+	"SGT":  8 * 3600,
+	"HKT":  8 * 3600,
+	"AWST": 8 * 3600,
+	"JST":  9 * 3600,
+	"KST":  9 * 3600,
+	"ACST": 9*3600 + 1800,
+	"ACDT": 10*3600 + 1800,
+	"AEST": 10 * 3600,
+	"AEDT": 11 * 3600,
+	"NZST": 12 * 3600,
+	"NZDT": 13 * 3600,
+	"NST":  -(3*3600 + 1800),
+	"NDT":  -(2*3600 + 1800),
+	"AST":  -4 * 3600,
+	"ADT":  -3 * 3600,
+	"EST":  -5 * 3600,
+	"EDT":  -4 * 3600,
+	"CST":  -6 * 3600, // US Central - also China (+08:00) and Cuba (-05:00)
+	"CDT":  -5 * 3600,
+	"MST":  -7 * 3600,
+	"MDT":  -6 * 3600,
+	"PST":  -8 * 3600,
+	"PDT":  -7 * 3600,
+	"AKST": -9 * 3600,
+	"AKDT": -8 * 3600,
+	"HST":  -10 * 3600,
+}
+
+// parseZoneOffset interprets an offset from UTC such as +05:30, -0800 or +01, and
+// returns it in seconds east of UTC.
+func parseZoneOffset(offset string) (int, error) {
+	for _, layout := range []string{"-07:00", "-0700", "-07"} {
+		if t, err := time.Parse(layout, offset); err == nil {
+			_, off := t.Zone()
+			return off, nil
+		}
+	}
+	return 0, fmt.Errorf("unexpected offset '%s' - expected e.g. +05:30", offset)
+}
+
+// abbreviations maps timezone abbreviations to zones with fixed offsets.
+type abbreviations map[string]*time.Location
+
+// newAbbreviations returns the built-in abbreviations, replaced or added to by
+// those in offsets.
+func newAbbreviations(offsets map[string]int) abbreviations {
+	res := make(abbreviations, len(defaultAbbreviations)+len(offsets))
+	for name, off := range defaultAbbreviations {
+		res[name] = time.FixedZone(name, off)
+	}
+	for name, off := range offsets {
+		res[name] = time.FixedZone(name, off)
+	}
+	return res
+}
+
+// builtinAbbreviations are used when no config is available.
+var builtinAbbreviations = newAbbreviations(nil)
+
+// resolve corrects the offset of t, parsed from ts, if ts names its timezone
+// by an abbreviation that's in the table, and Go couldn't give it an offset.
+// layout is the layout ts was parsed with, or "" if it was parsed by dateparse.
+// An offset in the timestamp itself, e.g. +0100 IST, wins over the table. The
+// clock time is unchanged.
+func (a abbreviations) resolve(t time.Time, ts string, layout string) time.Time {
+	name, off := t.Zone()
+	loc, ok := a[name]
+	switch {
+	case !ok || !strings.Contains(ts, name):
+		// Not an abbreviation, or not one from the timestamp - e.g. CEST is the
+		// zone of a timestamp without one, parsed in Europe/Berlin.
+		return t
+	case hasNumericZone(layout), layout == "" && off != 0:
+		return t
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
+
+// hasNumericZone returns true if layout includes an offset from UTC, e.g.
+// -0700 or Z07:00.
+func hasNumericZone(layout string) bool {
+	return strings.Contains(layout, "-07") || strings.Contains(layout, "Z07")
+}
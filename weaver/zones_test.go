@@ -0,0 +1,102 @@
+package weaver
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseZoneOffset(t *testing.T) {
+	tests := []struct {
+		offset string
+		want   int
+	}{
+		{"+05:30", 5*3600 + 1800},
+		{"-0800", -8 * 3600},
+		{"+01", 3600},
+		{"+00:00", 0},
+	}
+	for _, test := range tests {
+		got, err := parseZoneOffset(test.offset)
+		if assert.NoError(t, err, test.offset) {
+			assert.Equal(t, test.want, got, test.offset)
+		}
+	}
+
+	for _, offset := range []string{"", "IST", "+5:30", "0530"} {
+		_, err := parseZoneOffset(offset)
+		assert.Error(t, err, offset)
+	}
+}
+
+func TestAbbreviations(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		ts      string
+		offsets string // the config's [abbreviations], if any
+		want    time.Time
+	}{
+		{
+			name:   "from the table",
+			format: "2006-01-02 15:04:05 MST",
+			ts:     "2020-10-05 16:06:10 IST",
+			want:   time.Date(2020, time.October, 5, 10, 36, 10, 0, time.UTC),
+		},
+		{
+			name:    "from the config",
+			format:  "2006-01-02 15:04:05 MST",
+			ts:      "2020-10-05 16:06:10 IST",
+			offsets: "IST = '+01:00'",
+			want:    time.Date(2020, time.October, 5, 15, 6, 10, 0, time.UTC),
+		},
+		{
+			name:    "added by the config",
+			format:  "2006-01-02 15:04:05 MST",
+			ts:      "2020-10-05 16:06:10 XYZT",
+			offsets: "XYZT = '-03:00'",
+			want:    time.Date(2020, time.October, 5, 19, 6, 10, 0, time.UTC),
+		},
+		{
+			name:   "an explicit offset wins",
+			format: "2006-01-02 15:04:05 -0700 MST",
+			ts:     "2020-10-05 16:06:10 +0100 IST",
+			want:   time.Date(2020, time.October, 5, 15, 6, 10, 0, time.UTC),
+		},
+		{
+			name:   "an explicit offset of zero wins",
+			format: "%Y-%m-%d %H:%M:%S %:z %Z",
+			ts:     "2020-10-05 16:06:10 +00:00 CEST",
+			want:   time.Date(2020, time.October, 5, 16, 6, 10, 0, time.UTC),
+		},
+		{
+			name:   "parsed by dateparse",
+			format: "2006-01-02",
+			ts:     "Mon Oct 5 16:06:10 PDT 2020",
+			want:   time.Date(2020, time.October, 5, 23, 6, 10, 0, time.UTC),
+		},
+		{
+			name:   "unknown abbreviation",
+			format: "2006-01-02 15:04:05 MST",
+			ts:     "2020-10-05 16:06:10 QQQ",
+			want:   time.Date(2020, time.October, 5, 16, 6, 10, 0, time.UTC),
+		},
+	}
+	for _, test := range tests {
+		conf, err := DecodeConfig(strings.NewReader("[abbreviations]\n" + test.offsets + `
+[[match]]
+match = '^(.*)$'
+format = '` + test.format + `'
+`))
+		if !assert.NoError(t, err, test.name) {
+			continue
+		}
+		m := &conf.Match[0]
+		got, _, err := m.parseTimestamp(test.ts, nil, newAbbreviations(conf.offsets))
+		if assert.NoError(t, err, test.name) {
+			assert.True(t, test.want.Equal(got), "%s: got %v, want %v", test.name, got.UTC(), test.want)
+		}
+	}
+}